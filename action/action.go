@@ -16,63 +16,288 @@ package action
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/google/go-github/v35/github"
 	"github.com/rode/enforcer-action/config"
+	"github.com/rode/enforcer-action/discovery"
+	"github.com/rode/enforcer-action/resource"
 	rode "github.com/rode/rode/proto/v1alpha1"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 const (
 	githubPrEventName                 = "pull_request"
 	githubPrTargetEventName           = "pull_request_target"
+	githubIssueCommentEventName       = "issue_comment"
 	evaluationReportCommentIdentifier = "generated-by: enforcer-action"
+	evaluateCommandPrefix             = "/rode evaluate"
+	reactionPass                      = "+1"
+	reactionFail                      = "-1"
 )
 
-var osReadFile = os.ReadFile
+var (
+	osReadFile                       = os.ReadFile
+	discoveryFindImages              = discovery.FindImages
+	discoveryFindBuildMetadataImages = discovery.FindBuildMetadataImages
+	discoveryFindPolicyFiles         = discovery.FindPolicyFiles
+)
 
-type EnforcerAction struct {
-	config *config.Config
-	client rode.RodeClient
-	github *github.Client
-	logger *zap.Logger
+// DigestResolver resolves an image tag to a fully qualified digest URI
+type DigestResolver interface {
+	ResolveDigest(ctx context.Context, image string) (string, error)
 }
 
-type ActionResult struct {
-	Pass             bool
-	FailBuild        bool
-	EvaluationReport string
+// RodeClient is the subset of the Rode API used by the action, which can be served over gRPC or the HTTP gateway
+type RodeClient interface {
+	EvaluateResource(ctx context.Context, in *rode.ResourceEvaluationRequest, opts ...grpc.CallOption) (*rode.ResourceEvaluationResult, error)
+	GetPolicy(ctx context.Context, in *rode.GetPolicyRequest, opts ...grpc.CallOption) (*rode.Policy, error)
+	ListVersionedResourceOccurrences(ctx context.Context, in *rode.ListVersionedResourceOccurrencesRequest, opts ...grpc.CallOption) (*rode.ListVersionedResourceOccurrencesResponse, error)
+	BatchCreateOccurrences(ctx context.Context, in *rode.BatchCreateOccurrencesRequest, opts ...grpc.CallOption) (*rode.BatchCreateOccurrencesResponse, error)
+	GetResourceEvaluation(ctx context.Context, in *rode.GetResourceEvaluationRequest, opts ...grpc.CallOption) (*rode.ResourceEvaluationResult, error)
+	ListPolicyGroups(ctx context.Context, in *rode.ListPolicyGroupsRequest, opts ...grpc.CallOption) (*rode.ListPolicyGroupsResponse, error)
+	ListPolicyAssignments(ctx context.Context, in *rode.ListPolicyAssignmentsRequest, opts ...grpc.CallOption) (*rode.ListPolicyAssignmentsResponse, error)
+	GetPolicyGroup(ctx context.Context, in *rode.GetPolicyGroupRequest, opts ...grpc.CallOption) (*rode.PolicyGroup, error)
+	ValidatePolicy(ctx context.Context, in *rode.ValidatePolicyRequest, opts ...grpc.CallOption) (*rode.ValidatePolicyResponse, error)
 }
 
-type pullRequest struct {
-	Number int `json:"number"`
+type EnforcerAction struct {
+	config       *config.Config
+	client       RodeClient
+	github       *github.Client
+	resolver     DigestResolver
+	logger       *zap.Logger
+	policies     map[string]*rode.Policy
+	policyGroup  *policyGroupInfo
+	policyDrift  []string
+	resolvedTags map[string]string
+	warnings     []string
+	dryRun       bool
 }
 
-type pullRequestEvent struct {
-	PullRequest *pullRequest `json:"pull_request"`
+type ActionResult struct {
+	Pass                  bool              `json:"pass"`
+	FailBuild             bool              `json:"failBuild"`
+	Skipped               bool              `json:"skipped,omitempty"`
+	DryRun                bool              `json:"dryRun,omitempty"`
+	PolicyGroup           string            `json:"policyGroup,omitempty"`
+	Resources             []*ResourceResult `json:"resources,omitempty"`
+	Warnings              []string          `json:"warnings,omitempty"`
+	EvaluationReport      string            `json:"-"`
+	VerificationSummaries string            `json:"-"`
 }
 
-func NewEnforcerAction(logger *zap.Logger, conf *config.Config, client rode.RodeClient, githubClient *github.Client) *EnforcerAction {
+func NewEnforcerAction(logger *zap.Logger, conf *config.Config, client RodeClient, githubClient *github.Client, resolver DigestResolver) *EnforcerAction {
 	return &EnforcerAction{
-		conf,
-		client,
-		githubClient,
-		logger,
+		config:       conf,
+		client:       client,
+		github:       githubClient,
+		resolver:     resolver,
+		logger:       logger,
+		policies:     map[string]*rode.Policy{},
+		resolvedTags: map[string]string{},
 	}
 }
 
 func (a *EnforcerAction) Run(ctx context.Context) (*ActionResult, error) {
-	a.logger.Info("Evaluating resource", zap.String("policyGroup", a.config.PolicyGroup), zap.String("resourceUri", a.config.ResourceUri))
+	policyGroup := a.config.PolicyGroup
+	if a.config.Rule != nil {
+		a.logger.Info("Matched enforcement rule", zap.String("rule", a.config.Rule.Name), zap.String("policyGroup", policyGroup), zap.Bool("enforce", a.config.Enforce))
+	}
+
+	if a.config.GitHub.EventName != githubIssueCommentEventName {
+		return a.run(ctx, policyGroup)
+	}
+
+	command, err := a.parseEvaluateCommand()
+	if err != nil {
+		return nil, err
+	}
+
+	if command == nil {
+		a.logger.Info("Comment does not contain an evaluate command, skipping evaluation")
+		return &ActionResult{Pass: true, Skipped: true}, nil
+	}
+
+	result, err := a.runCommand(ctx, policyGroup, command)
+
+	// the command is answered even when the evaluation errors, so that it doesn't look like it was ignored
+	if reactErr := a.reactToCommand(ctx, command, err == nil && result.Pass); reactErr != nil {
+		if err != nil {
+			a.logger.Error("Failed to react to evaluate command", zap.Error(reactErr))
+			return nil, err
+		}
+
+		return nil, reactErr
+	}
+
+	return result, err
+}
+
+// runCommand evaluates the resources for a /rode evaluate command, which may only select the policy groups in command-policy-groups
+func (a *EnforcerAction) runCommand(ctx context.Context, policyGroup string, command *evaluateCommand) (*ActionResult, error) {
+	if command.policyGroup != "" && command.policyGroup != policyGroup {
+		if !contains(a.config.CommandPolicyGroups, command.policyGroup) {
+			return nil, fmt.Errorf("policy group %q can't be selected by an evaluate command, add it to command-policy-groups to allow it", command.policyGroup)
+		}
+
+		policyGroup = command.policyGroup
+	}
+
+	return a.run(ctx, policyGroup)
+}
+
+// run evaluates every resource against the policy group and reports the results
+func (a *EnforcerAction) run(ctx context.Context, policyGroup string) (*ActionResult, error) {
+	if err := a.checkPolicyGroup(ctx, policyGroup); err != nil {
+		return nil, err
+	}
+
+	resources, err := a.collectResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pass := true
+	failBuild := false
+	var evaluations []*resourceEvaluation
+	for _, t := range resources {
+		evaluation, err := a.evaluate(ctx, policyGroup, t)
+		if err != nil {
+			return nil, err
+		}
+
+		pass = pass && evaluation.pass()
+		failBuild = failBuild || evaluation.failBuild
+		evaluations = append(evaluations, evaluation)
+	}
+
+	if a.config.RecordDecision {
+		if err = a.recordDecisions(ctx, policyGroup, evaluations); err != nil {
+			return nil, err
+		}
+	}
+
+	report, summary, err := a.createEvaluationReport(ctx, evaluations)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := a.collectResults(ctx, evaluations)
+	if err != nil {
+		return nil, err
+	}
+
+	var verificationSummaries string
+	if a.config.Vsa.SigningKey != "" {
+		verificationSummaries, err = a.createVerificationSummaries(policyGroup, evaluations)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.config.DecoratePullRequest && a.config.GitHub.Actions {
+		if err = a.decoratePullRequest(ctx, report, summary); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ActionResult{
+		FailBuild:             a.config.Enforce && failBuild,
+		Pass:                  pass,
+		PolicyGroup:           policyGroup,
+		Resources:             results,
+		Warnings:              a.plainWarnings(),
+		EvaluationReport:      report,
+		VerificationSummaries: verificationSummaries,
+	}, nil
+}
+
+// evaluate evaluates a single resource, comparing it against the baseline if one is configured for the resource
+func (a *EnforcerAction) evaluate(ctx context.Context, policyGroup string, t *target) (*resourceEvaluation, error) {
+	resourceUri, err := a.prepareResourceUri(ctx, t.uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.config.UploadProvenance && t.built {
+		if err = a.uploadProvenance(ctx, resourceUri); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = a.waitForOccurrences(ctx, resourceUri); err != nil {
+		return nil, err
+	}
+
+	result, err := a.evaluateResource(ctx, policyGroup, resourceUri)
+	if err != nil {
+		return nil, err
+	}
+
+	shortfalls, err := a.policyShortfalls(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation := &resourceEvaluation{
+		target:     t,
+		result:     result,
+		shortfalls: shortfalls,
+	}
+	evaluation.failBuild = !evaluation.pass()
+
+	if a.config.BaselineResourceUri != "" && t.uri == a.config.ResourceUri {
+		baselineUri, err := a.prepareResourceUri(ctx, a.config.BaselineResourceUri)
+		if err != nil {
+			return nil, err
+		}
+
+		baseline, err := a.evaluateResource(ctx, policyGroup, baselineUri)
+		if err != nil {
+			return nil, err
+		}
+
+		evaluation.comparison, err = a.compareToBaseline(ctx, baseline, result)
+		if err != nil {
+			return nil, err
+		}
+
+		if a.config.FailOnRegression {
+			evaluation.failBuild = evaluation.comparison.hasRegressions() || len(evaluation.shortfalls) > 0
+		}
+	}
+
+	return evaluation, nil
+}
+
+// prepareResourceUri resolves image tags and validates the resulting uri, so that typos aren't sent to Rode
+func (a *EnforcerAction) prepareResourceUri(ctx context.Context, resourceUri string) (string, error) {
+	resourceUri, err := a.resolveResourceUri(ctx, resourceUri)
+	if err != nil {
+		return "", err
+	}
+
+	// images discovered without a digest have already been reported as warnings
+	if _, err = resource.Parse(resourceUri); err != nil && !errors.Is(err, resource.ErrUnpinnedImage) {
+		return "", fmt.Errorf("invalid resource uri: %s", err)
+	}
+
+	return resourceUri, nil
+}
+
+func (a *EnforcerAction) evaluateResource(ctx context.Context, policyGroup, resourceUri string) (*rode.ResourceEvaluationResult, error) {
+	a.logger.Info("Evaluating resource", zap.String("policyGroup", policyGroup), zap.String("resourceUri", resourceUri))
 	response, err := a.client.EvaluateResource(ctx, &rode.ResourceEvaluationRequest{
-		PolicyGroup: a.config.PolicyGroup,
-		ResourceUri: a.config.ResourceUri,
+		PolicyGroup: policyGroup,
+		ResourceUri: resourceUri,
 		Source: &rode.ResourceEvaluationSource{
 			Name: "enforcer-action",
-			Url:  fmt.Sprintf("%s/%s/actions/runs/%d", a.config.GitHub.ServerUrl, a.config.GitHub.Repository, a.config.GitHub.RunId),
+			Url:  a.runUrl(),
 		},
 	})
 
@@ -80,32 +305,105 @@ func (a *EnforcerAction) Run(ctx context.Context) (*ActionResult, error) {
 		return nil, fmt.Errorf("error evaluating resource: %s", err)
 	}
 
-	report, err := a.createEvaluationReport(ctx, response)
-	if err != nil {
-		return nil, err
+	return response, nil
+}
+
+// runUrl links to the workflow run, which doesn't exist outside of GitHub Actions
+func (a *EnforcerAction) runUrl() string {
+	if !a.config.GitHub.Actions {
+		return ""
 	}
 
-	if err = a.decoratePullRequest(ctx, report); err != nil {
+	return fmt.Sprintf("%s/%s/actions/runs/%d", a.config.GitHub.ServerUrl, a.config.GitHub.Repository, a.config.GitHub.RunId)
+}
+
+// getPolicy fetches a policy by its version id, caching the result since the same policy may be reported on more than once
+func (a *EnforcerAction) getPolicy(ctx context.Context, policyVersionId string) (*rode.Policy, error) {
+	if policy, ok := a.policies[policyVersionId]; ok {
+		return policy, nil
+	}
+
+	policy, err := a.client.GetPolicy(ctx, &rode.GetPolicyRequest{Id: policyVersionId})
+	if err != nil {
 		return nil, err
 	}
 
-	return &ActionResult{
-		FailBuild:        a.config.Enforce && !response.ResourceEvaluation.Pass,
-		Pass:             response.ResourceEvaluation.Pass,
-		EvaluationReport: report,
-	}, nil
+	a.policies[policyVersionId] = policy
+
+	return policy, nil
 }
 
-func (a *EnforcerAction) createEvaluationReport(ctx context.Context, evaluationResult *rode.ResourceEvaluationResult) (string, error) {
-	resourceEval := evaluationResult.ResourceEvaluation
+func (a *EnforcerAction) createEvaluationReport(ctx context.Context, evaluations []*resourceEvaluation) (string, *evaluationSummary, error) {
+	pass := true
+	for _, evaluation := range evaluations {
+		pass = pass && evaluation.pass()
+	}
+
+	summary := newEvaluationSummary()
 	md := markdownPrinter{}
+	md.h1("Rode Resource Evaluation Report %s", statusMessage(pass))
+	if a.config.Rule != nil {
+		md.quote(fmt.Sprintf("enforcement rule: %s (%s)", a.config.Rule.Name, enforcementMode(a.config.Enforce)))
+	}
+	if a.policyGroup != nil {
+		md.quote(a.policyGroup.header())
+	}
+
+	if a.dryRun {
+		md.quote("dry run of local policies, nothing was saved in Rode")
+	}
+
+	if len(evaluations) == 1 {
+		if !a.dryRun {
+			md.quote("report id: " + evaluations[0].result.ResourceEvaluation.Id)
+		}
+		a.renderWarnings(&md, 2)
+		if err := a.renderResourceEvaluation(ctx, &md, evaluations[0], summary, 2); err != nil {
+			return "", nil, err
+		}
+	} else {
+		var rows [][]string
+		for _, evaluation := range evaluations {
+			resourceEval := evaluation.result.ResourceEvaluation
+			rows = append(rows, []string{asCode(resourceEval.ResourceVersion.Version), statusMessage(evaluation.pass()), resourceEval.Id})
+		}
+
+		md.h2("Resources").table([]string{"Resource URI", "Result", "Report ID"}, rows)
+		a.renderWarnings(&md, 2)
+
+		for _, evaluation := range evaluations {
+			resourceEval := evaluation.result.ResourceEvaluation
+			md.
+				rule().
+				h2("%s %s", asCode(evaluation.target.label()), statusMessage(evaluation.pass())).
+				quote("report id: " + resourceEval.Id)
+
+			if err := a.renderResourceEvaluation(ctx, &md, evaluation, summary, 3); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	encodedSummary, err := summary.encode()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// leave HTML comments in the markdown so that we can find the comment and compare results on future job runs
+	md.comment(evaluationSummaryCommentPrefix + encodedSummary)
+	md.comment(evaluationReportCommentIdentifier)
+
+	return md.string(), summary, nil
+}
+
+// renderResourceEvaluation writes the metadata and policy results for a single resource, starting at the given header depth.
+// Policy results are recorded in the summary under the resource label and policy name, so that results for multiple resources don't collide.
+func (a *EnforcerAction) renderResourceEvaluation(ctx context.Context, md *markdownPrinter, evaluation *resourceEvaluation, summary *evaluationSummary, depth int) error {
+	resourceEval := evaluation.result.ResourceEvaluation
+	metadataHeaders, metadata := a.resourceMetadata(resourceEval.ResourceVersion.Version)
 	md.
-		h1("Rode Resource Evaluation Report %s", statusMessage(resourceEval.Pass)).
-		quote("report id: "+resourceEval.Id).
-		h2("Resource Metadata").
-		table([]string{"Resource URI"}, [][]string{
-			{asCode(resourceEval.ResourceVersion.Version)},
-		})
+		header(depth, "Resource Metadata").
+		table(metadataHeaders, [][]string{metadata})
 
 	if len(resourceEval.ResourceVersion.Names) > 0 {
 		var artifactNames []string
@@ -113,18 +411,32 @@ func (a *EnforcerAction) createEvaluationReport(ctx context.Context, evaluationR
 			artifactNames = append(artifactNames, asCode(name))
 		}
 
-		md.h3("Artifact Names").list(artifactNames).newline()
+		md.header(depth+1, "Artifact Names").list(artifactNames).newline()
+	}
+
+	if evaluation.comparison != nil {
+		a.renderBaselineComparison(md, evaluation.comparison, depth)
 	}
 
-	md.h2("Policy Results")
-	for _, result := range evaluationResult.PolicyEvaluations {
-		policy, err := a.client.GetPolicy(ctx, &rode.GetPolicyRequest{Id: result.PolicyVersionId})
+	a.renderPolicyShortfalls(md, evaluation.shortfalls, depth)
+
+	md.header(depth, "Policy Results")
+	for _, result := range evaluation.result.PolicyEvaluations {
+		policy, err := a.getPolicy(ctx, result.PolicyVersionId)
 		if err != nil {
-			return "", err
+			return err
+		}
+
+		version := "Policy version: " + asCode(result.PolicyVersionId)
+		if drift := a.policyVersionDrift(policy); drift != "" {
+			version += " ⚠️ drift: " + drift
 		}
 
+		summary.Policies[evaluation.target.label()+": "+policy.Name] = result.Pass
 		md.
-			h3("%s %s", policy.Name, statusMessage(result.Pass)).
+			header(depth+1, "%s %s", policy.Name, statusMessage(result.Pass)).
+			write(version).
+			newline().
 			codeBlock()
 
 		for _, v := range result.Violations {
@@ -133,39 +445,25 @@ func (a *EnforcerAction) createEvaluationReport(ctx context.Context, evaluationR
 		md.codeBlock().newline()
 	}
 
-	// leave an HTML comment in the markdown so that we can find the comment on future job runs
-	md.comment(evaluationReportCommentIdentifier)
-
-	return md.string(), nil
+	return nil
 }
 
-func (a *EnforcerAction) decoratePullRequest(ctx context.Context, comment string) error {
-	shouldDecoratePullRequest := (a.config.GitHub.EventName == githubPrEventName ||
-		a.config.GitHub.EventName == githubPrTargetEventName) && a.config.GitHub.EventPath != ""
-
-	if !shouldDecoratePullRequest {
-		a.logger.Info("Skipping pull request decoration")
-		return nil
-	}
-
-	eventJson, err := osReadFile(a.config.GitHub.EventPath)
+func (a *EnforcerAction) decoratePullRequest(ctx context.Context, comment string, summary *evaluationSummary) error {
+	prNumber, err := a.pullRequestNumber()
 	if err != nil {
-		return fmt.Errorf("error reading event payload at %s: %s", a.config.GitHub.EventPath, err)
+		return err
 	}
 
-	var prEvent pullRequestEvent
-	if err := json.Unmarshal(eventJson, &prEvent); err != nil {
-		return fmt.Errorf("error unmarshalling event json: %s", err)
+	if prNumber == 0 {
+		a.logger.Info("Skipping pull request decoration")
+		return nil
 	}
 
-	a.logger.Info("Decorating pull request", zap.Int("pr", prEvent.PullRequest.Number))
-	// repository here is the owner/repo slug provided by the environment variable GITHUB_REPOSITORY, which is set by default when running in GitHub Actions
-	slug := strings.Split(a.config.GitHub.Repository, "/")
-	org := slug[0]
-	repo := slug[1]
+	a.logger.Info("Decorating pull request", zap.Int("pr", prNumber))
+	org, repo := a.repositorySlug()
 
-	var existingCommentId int64
-	comments, _, err := a.github.Issues.ListComments(ctx, org, repo, prEvent.PullRequest.Number, &github.IssueListCommentsOptions{
+	var existingComment *github.IssueComment
+	comments, _, err := a.github.Issues.ListComments(ctx, org, repo, prNumber, &github.IssueListCommentsOptions{
 		ListOptions: github.ListOptions{
 			PerPage: 100,
 		},
@@ -177,14 +475,25 @@ func (a *EnforcerAction) decoratePullRequest(ctx context.Context, comment string
 
 	for _, prComment := range comments {
 		if strings.Contains(*prComment.Body, evaluationReportCommentIdentifier) {
-			existingCommentId = *prComment.ID
+			existingComment = prComment
 			break
 		}
 	}
 
-	if existingCommentId != 0 {
+	if existingComment != nil {
+		existingCommentId := existingComment.GetID()
 		a.logger.Info("Found existing comment, updating", zap.Int64("commentId", existingCommentId))
-		_, _, err := a.github.Issues.EditComment(ctx, org, repo, existingCommentId, &github.IssueComment{
+
+		previousSummary, err := parseEvaluationSummary(existingComment.GetBody())
+		if err != nil {
+			a.logger.Warn("Unable to parse the previous evaluation results", zap.Error(err))
+		}
+
+		if previousSummary != nil {
+			comment += renderEvaluationChanges(previousSummary, summary)
+		}
+
+		_, _, err = a.github.Issues.EditComment(ctx, org, repo, existingCommentId, &github.IssueComment{
 			Body: github.String(comment),
 		})
 
@@ -197,7 +506,7 @@ func (a *EnforcerAction) decoratePullRequest(ctx context.Context, comment string
 
 	// use the issues API to post a comment that's not attached to a line in the pull request diff
 	// see https://docs.github.com/en/rest/reference/pulls#create-a-review-comment-for-a-pull-request
-	_, _, err = a.github.Issues.CreateComment(ctx, org, repo, prEvent.PullRequest.Number, &github.IssueComment{
+	_, _, err = a.github.Issues.CreateComment(ctx, org, repo, prNumber, &github.IssueComment{
 		Body: github.String(comment),
 	})
 
@@ -208,6 +517,33 @@ func (a *EnforcerAction) decoratePullRequest(ctx context.Context, comment string
 	return nil
 }
 
+func (a *EnforcerAction) reactToCommand(ctx context.Context, command *evaluateCommand, pass bool) error {
+	// the GitHub client is only created in GitHub Actions
+	if !a.config.GitHub.Actions {
+		return nil
+	}
+
+	reaction := reactionFail
+	if pass {
+		reaction = reactionPass
+	}
+
+	org, repo := a.repositorySlug()
+	a.logger.Info("Reacting to evaluate command", zap.Int64("commentId", command.commentId), zap.String("reaction", reaction))
+	if _, _, err := a.github.Reactions.CreateIssueCommentReaction(ctx, org, repo, command.commentId, reaction); err != nil {
+		return fmt.Errorf("error reacting to comment (id: %d): %s", command.commentId, err)
+	}
+
+	return nil
+}
+
+// repositorySlug splits the owner/repo slug provided by the environment variable GITHUB_REPOSITORY, which is set by default when running in GitHub Actions
+func (a *EnforcerAction) repositorySlug() (string, string) {
+	slug := strings.Split(a.config.GitHub.Repository, "/")
+
+	return slug[0], slug[1]
+}
+
 func statusMessage(pass bool) string {
 	if pass {
 		return "✅ (PASSED)"
@@ -215,3 +551,11 @@ func statusMessage(pass bool) string {
 
 	return "❌ (FAILED)"
 }
+
+func enforcementMode(enforce bool) string {
+	if enforce {
+		return "enforced"
+	}
+
+	return "advisory"
+}
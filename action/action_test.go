@@ -17,22 +17,36 @@ package action
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v35/github"
 	"github.com/jarcoal/httpmock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/rode/enforcer-action/config"
+	"github.com/rode/enforcer-action/discovery"
+	"github.com/rode/enforcer-action/intoto"
+	"github.com/rode/rode/common"
 	rode "github.com/rode/rode/proto/v1alpha1"
 	"github.com/rode/rode/proto/v1alpha1fakes"
+	grafeas_common_proto "github.com/rode/rode/protodeps/grafeas/proto/v1beta1/common_go_proto"
+	"github.com/rode/rode/protodeps/grafeas/proto/v1beta1/grafeas_go_proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 var _ = Describe("EnforcerAction", func() {
@@ -41,6 +55,7 @@ var _ = Describe("EnforcerAction", func() {
 		conf         *config.Config
 		rodeClient   *v1alpha1fakes.FakeRodeClient
 		githubClient *github.Client
+		resolver     *fakeDigestResolver
 		action       *EnforcerAction
 
 		expectedPolicyGroup string
@@ -55,24 +70,34 @@ var _ = Describe("EnforcerAction", func() {
 		httpmock.ActivateNonDefault(httpClient)
 		githubClient = github.NewClient(httpClient)
 		rodeClient = &v1alpha1fakes.FakeRodeClient{}
+		resolver = &fakeDigestResolver{}
 		expectedPolicyGroup = fake.LetterN(10)
-		expectedResourceUri = fake.URL()
+		rodeClient.GetPolicyGroupReturns(&rode.PolicyGroup{Name: expectedPolicyGroup}, nil)
+		rodeClient.ListPolicyAssignmentsReturns(&rode.ListPolicyAssignmentsResponse{
+			PolicyAssignments: []*rode.PolicyAssignment{{PolicyVersionId: fake.UUID(), PolicyGroup: expectedPolicyGroup}},
+		}, nil)
+		expectedResourceUri = fakeImageDigestUri()
 		expectedOrg = fake.LetterN(10)
 		expectedRepo = fake.LetterN(10)
 
 		conf = &config.Config{
-			Enforce:     true,
-			ResourceUri: expectedResourceUri,
-			PolicyGroup: expectedPolicyGroup,
+			Enforce:             true,
+			ResourceUri:         expectedResourceUri,
+			PolicyGroup:         expectedPolicyGroup,
+			DecoratePullRequest: true,
 			GitHub: &config.GitHubConfig{
+				Actions:    true,
 				EventName:  fake.Word(),
 				ServerUrl:  fake.URL(),
 				Repository: fmt.Sprintf("%s/%s", expectedOrg, expectedRepo),
 				RunId:      fake.Number(10, 100),
 			},
+			Registry: &config.RegistryConfig{},
+			Wait:     &config.WaitConfig{},
+			Vsa:      &config.VsaConfig{},
 		}
 
-		action = NewEnforcerAction(logger, conf, rodeClient, githubClient)
+		action = NewEnforcerAction(logger, conf, rodeClient, githubClient, resolver)
 	})
 
 	AfterEach(func() {
@@ -119,7 +144,7 @@ var _ = Describe("EnforcerAction", func() {
 						},
 					},
 				}
-				expectedPolicyNames[evaluation.Id] = fake.Word()
+				expectedPolicyNames[evaluation.PolicyVersionId] = fake.LetterN(10)
 				resourceEvaluationResult.PolicyEvaluations = append(resourceEvaluationResult.PolicyEvaluations, evaluation)
 			}
 
@@ -134,7 +159,10 @@ var _ = Describe("EnforcerAction", func() {
 		})
 
 		JustBeforeEach(func() {
-			rodeClient.EvaluateResourceReturns(resourceEvaluationResult, resourceEvaluationError)
+			// setting the return values would clear any stub configured by a test
+			if rodeClient.EvaluateResourceStub == nil {
+				rodeClient.EvaluateResourceReturns(resourceEvaluationResult, resourceEvaluationError)
+			}
 
 			actualResult, actualError = action.Run(ctx)
 		})
@@ -181,6 +209,256 @@ var _ = Describe("EnforcerAction", func() {
 				Expect(actualError).To(BeNil())
 			})
 
+			It("should return the policy results", func() {
+				Expect(actualResult.PolicyGroup).To(Equal(expectedPolicyGroup))
+				Expect(actualResult.Resources).To(HaveLen(1))
+
+				resourceResult := actualResult.Resources[0]
+				Expect(resourceResult.ResourceUri).To(Equal(resourceEvaluationResult.ResourceEvaluation.ResourceVersion.Version))
+				Expect(resourceResult.EvaluationId).To(Equal(resourceEvaluationResult.ResourceEvaluation.Id))
+				Expect(resourceResult.Pass).To(BeTrue())
+				Expect(resourceResult.Policies).To(HaveLen(policyEvaluationsCount))
+
+				for i, policyEvaluation := range resourceEvaluationResult.PolicyEvaluations {
+					Expect(resourceResult.Policies[i]).To(Equal(&PolicyResult{
+						Name:            expectedPolicyNames[policyEvaluation.PolicyVersionId],
+						PolicyVersionId: policyEvaluation.PolicyVersionId,
+						Pass:            policyEvaluation.Pass,
+						Violations:      []string{policyEvaluation.Violations[0].Message, policyEvaluation.Violations[1].Message},
+					}))
+				}
+			})
+
+			When("not running in GitHub Actions", func() {
+				BeforeEach(func() {
+					conf.GitHub.Actions = false
+				})
+
+				It("should not link the evaluation to a workflow run", func() {
+					_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(0)
+					Expect(actualRequest.Source.Name).To(Equal("enforcer-action"))
+					Expect(actualRequest.Source.Url).To(BeEmpty())
+				})
+			})
+
+			It("should not include an enforcement rule in the report", func() {
+				Expect(actualResult.EvaluationReport).NotTo(ContainSubstring("enforcement rule"))
+			})
+
+			When("fewer policies are evaluated than min-policies", func() {
+				BeforeEach(func() {
+					conf.MinPolicies = policyEvaluationsCount + 1
+				})
+
+				It("should fail the evaluation and explain why", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+					Expect(actualResult.Pass).To(BeFalse())
+					Expect(actualResult.FailBuild).To(BeTrue())
+					Expect(actualResult.EvaluationReport).To(ContainSubstring("Resource Evaluation Report ❌ (FAILED)"))
+					Expect(actualResult.EvaluationReport).To(ContainSubstring("Policy Requirements"))
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("%d policies were evaluated, but at least %d are required", policyEvaluationsCount, policyEvaluationsCount+1)))
+					Expect(actualResult.Resources[0].Pass).To(BeFalse())
+					Expect(actualResult.Resources[0].Shortfalls).To(HaveLen(1))
+				})
+
+				When("enforcement is disabled", func() {
+					BeforeEach(func() {
+						conf.Enforce = false
+					})
+
+					It("should not fail the build", func() {
+						Expect(actualResult.Pass).To(BeFalse())
+						Expect(actualResult.FailBuild).To(BeFalse())
+					})
+				})
+			})
+
+			When("no policies are evaluated", func() {
+				BeforeEach(func() {
+					conf.MinPolicies = 1
+					resourceEvaluationResult.PolicyEvaluations = nil
+				})
+
+				It("should fail the evaluation", func() {
+					Expect(actualResult.Pass).To(BeFalse())
+					Expect(actualResult.EvaluationReport).To(ContainSubstring("0 policies were evaluated, but at least 1 is required"))
+				})
+			})
+
+			When("the required policies were evaluated", func() {
+				BeforeEach(func() {
+					first := resourceEvaluationResult.PolicyEvaluations[0].PolicyVersionId
+					conf.RequiredPolicies = []string{expectedPolicyNames[first], first}
+				})
+
+				It("should pass", func() {
+					Expect(actualResult.Pass).To(BeTrue())
+					Expect(actualResult.EvaluationReport).NotTo(ContainSubstring("Policy Requirements"))
+				})
+			})
+
+			When("a required policy wasn't evaluated", func() {
+				var missingPolicy string
+
+				BeforeEach(func() {
+					missingPolicy = fake.LetterN(12)
+					conf.RequiredPolicies = []string{missingPolicy}
+				})
+
+				It("should fail the evaluation and name the policy", func() {
+					Expect(actualResult.Pass).To(BeFalse())
+					Expect(actualResult.FailBuild).To(BeTrue())
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("required policy %q was not evaluated", missingPolicy)))
+				})
+			})
+
+			It("should include the policy version id of each policy in the report", func() {
+				for _, policyEval := range resourceEvaluationResult.PolicyEvaluations {
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("Policy version: `%s`\n", policyEval.PolicyVersionId)))
+				}
+				Expect(actualResult.EvaluationReport).NotTo(ContainSubstring("drift"))
+			})
+
+			When("an assigned policy drifted from its pinned version", func() {
+				var (
+					driftedPolicyVersionId string
+					driftedPolicyName      string
+				)
+
+				BeforeEach(func() {
+					driftedPolicyVersionId = resourceEvaluationResult.PolicyEvaluations[0].PolicyVersionId
+					driftedPolicyName = expectedPolicyNames[driftedPolicyVersionId]
+					rodeClient.ListPolicyAssignmentsReturns(&rode.ListPolicyAssignmentsResponse{
+						PolicyAssignments: []*rode.PolicyAssignment{{PolicyVersionId: driftedPolicyVersionId, PolicyGroup: expectedPolicyGroup}},
+					}, nil)
+					rodeClient.GetPolicyStub = func(_ context.Context, request *rode.GetPolicyRequest, _ ...grpc.CallOption) (*rode.Policy, error) {
+						return &rode.Policy{
+							Id:     request.Id,
+							Name:   expectedPolicyNames[request.Id],
+							Policy: &rode.PolicyEntity{Version: 4},
+						}, nil
+					}
+					conf.PolicyVersions = map[string]uint32{driftedPolicyName: 3}
+					conf.PolicyVersionDrift = config.PolicyVersionDriftFail
+				})
+
+				It("should fail the evaluation and mark the drifted policy", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+					Expect(actualResult.Pass).To(BeFalse())
+					Expect(actualResult.FailBuild).To(BeTrue())
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("policy %q is assigned at version 4, but version 3 is pinned", driftedPolicyName)))
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("Policy version: `%s` ⚠️ drift: version 3 is pinned\n", driftedPolicyVersionId)))
+					Expect(actualResult.Resources[0].Policies[0].Drift).To(Equal("version 3 is pinned"))
+				})
+
+				When("policy-version-drift is warn", func() {
+					BeforeEach(func() {
+						conf.PolicyVersionDrift = config.PolicyVersionDriftWarn
+					})
+
+					It("should warn about the drift without failing the evaluation", func() {
+						Expect(actualResult.Pass).To(BeTrue())
+						Expect(actualResult.Warnings).To(ConsistOf(fmt.Sprintf("policy %q is assigned at version 4, but version 3 is pinned", driftedPolicyName)))
+						Expect(actualResult.EvaluationReport).To(ContainSubstring("⚠️ drift: version 3 is pinned"))
+					})
+				})
+			})
+
+			It("should look up the policy group before evaluating", func() {
+				Expect(rodeClient.GetPolicyGroupCallCount()).To(Equal(1))
+				_, groupRequest, _ := rodeClient.GetPolicyGroupArgsForCall(0)
+				Expect(groupRequest.Name).To(Equal(expectedPolicyGroup))
+
+				Expect(rodeClient.ListPolicyAssignmentsCallCount()).To(Equal(1))
+				_, assignmentsRequest, _ := rodeClient.ListPolicyAssignmentsArgsForCall(0)
+				Expect(assignmentsRequest.PolicyGroup).To(Equal(expectedPolicyGroup))
+			})
+
+			It("should include the policy group in the report header", func() {
+				Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("> policy group: %s (1 assigned policy)\n", expectedPolicyGroup)))
+			})
+
+			When("the policy group has a description", func() {
+				var description string
+
+				BeforeEach(func() {
+					description = fake.Sentence(4)
+					rodeClient.GetPolicyGroupReturns(&rode.PolicyGroup{Name: expectedPolicyGroup, Description: description}, nil)
+					rodeClient.ListPolicyAssignmentsReturns(&rode.ListPolicyAssignmentsResponse{
+						PolicyAssignments: []*rode.PolicyAssignment{{PolicyVersionId: fake.UUID()}, {PolicyVersionId: fake.UUID()}},
+					}, nil)
+				})
+
+				It("should include the description and assignment count in the report header", func() {
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("> policy group: %s (2 assigned policies) - %s\n", expectedPolicyGroup, description)))
+				})
+			})
+
+			When("the policy group has no assigned policies", func() {
+				BeforeEach(func() {
+					rodeClient.ListPolicyAssignmentsReturns(&rode.ListPolicyAssignmentsResponse{}, nil)
+				})
+
+				It("should warn that the evaluation passes vacuously", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("policy group `%s` has no assigned policies", expectedPolicyGroup)))
+					Expect(actualResult.Warnings).To(ConsistOf(fmt.Sprintf("policy group %s has no assigned policies, so every resource passes", expectedPolicyGroup)))
+				})
+			})
+
+			When("the policy group doesn't exist", func() {
+				BeforeEach(func() {
+					rodeClient.GetPolicyGroupReturns(nil, status.Error(codes.NotFound, "not found"))
+					rodeClient.ListPolicyGroupsReturns(&rode.ListPolicyGroupsResponse{
+						PolicyGroups: []*rode.PolicyGroup{
+							{Name: expectedPolicyGroup[:9]},
+							{Name: "unrelated-group-name"},
+						},
+					}, nil)
+				})
+
+				It("should suggest similar policy groups without evaluating", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(fmt.Sprintf("policy group %q doesn't exist, did you mean %q?", expectedPolicyGroup, expectedPolicyGroup[:9])))
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+				})
+
+				When("no policy groups are similar", func() {
+					BeforeEach(func() {
+						rodeClient.ListPolicyGroupsReturns(&rode.ListPolicyGroupsResponse{
+							PolicyGroups: []*rode.PolicyGroup{{Name: "unrelated-group-name"}},
+						}, nil)
+					})
+
+					It("should point to the groups list command", func() {
+						Expect(actualError).To(MatchError(ContainSubstring("run the groups list command")))
+					})
+				})
+			})
+
+			When("the policy group can't be fetched", func() {
+				BeforeEach(func() {
+					rodeClient.GetPolicyGroupReturns(nil, status.Error(codes.Unavailable, fake.Word()))
+				})
+
+				It("should return an error without evaluating", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring("error fetching policy group " + expectedPolicyGroup)))
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+				})
+			})
+
+			When("an enforcement rule matched", func() {
+				BeforeEach(func() {
+					conf.Enforce = false
+					conf.Rule = &config.Rule{Name: fake.Word()}
+				})
+
+				It("should include the rule in the report", func() {
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("enforcement rule: %s (advisory)", conf.Rule.Name)))
+				})
+			})
+
 			When("the resource version has additional artifact names", func() {
 				var expectedNames []string
 
@@ -297,6 +575,49 @@ var _ = Describe("EnforcerAction", func() {
 						Expect(httpmock.GetTotalCallCount()).To(Equal(2))
 					})
 
+					When("the existing comment contains the previous results", func() {
+						var (
+							newlyFailingPolicy string
+							unchangedPolicy    string
+						)
+
+						BeforeEach(func() {
+							for _, evaluation := range resourceEvaluationResult.PolicyEvaluations {
+								evaluation.Pass = true
+							}
+							resourceEvaluationResult.PolicyEvaluations[0].Pass = false
+							label := (&target{uri: expectedResourceUri}).label() + ": "
+							newlyFailingPolicy = label + expectedPolicyNames[resourceEvaluationResult.PolicyEvaluations[0].PolicyVersionId]
+							unchangedPolicy = label + expectedPolicyNames[resourceEvaluationResult.PolicyEvaluations[1].PolicyVersionId]
+
+							previous := newEvaluationSummary()
+							for _, name := range expectedPolicyNames {
+								previous.Policies[label+name] = true
+							}
+							encoded, _ := previous.encode()
+
+							body, _ := json.Marshal([]*github.IssueComment{
+								{
+									ID:   github.Int64(expectedCommentId),
+									Body: github.String(fmt.Sprintf("<!---%s%s---><!---%s--->", evaluationSummaryCommentPrefix, encoded, evaluationReportCommentIdentifier)),
+								},
+							})
+							listCommentsResponse.Body = io.NopCloser(bytes.NewReader(body))
+						})
+
+						It("should include the changes since the last evaluation", func() {
+							body, err := io.ReadAll(createOrEditCommentRequest.Body)
+							Expect(err).NotTo(HaveOccurred())
+							var actualComment github.IssueComment
+							Expect(json.Unmarshal(body, &actualComment)).NotTo(HaveOccurred())
+
+							changes := strings.Split(*actualComment.Body, "Changes since last evaluation")
+							Expect(changes).To(HaveLen(2))
+							Expect(changes[1]).To(ContainSubstring(fmt.Sprintf("### Newly Failing\n\n- `%s`", newlyFailingPolicy)))
+							Expect(changes[1]).To(ContainSubstring(fmt.Sprintf("- `%s` ✅ (PASSED)", unchangedPolicy)))
+						})
+					})
+
 					When("an error occurs updating the current comment", func() {
 						BeforeEach(func() {
 							editCommentResponse.StatusCode = http.StatusInternalServerError
@@ -309,6 +630,28 @@ var _ = Describe("EnforcerAction", func() {
 					})
 				})
 
+				When("pull request decoration is disabled", func() {
+					BeforeEach(func() {
+						conf.DecoratePullRequest = false
+					})
+
+					It("should not comment on the pull request", func() {
+						Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+						Expect(actualError).NotTo(HaveOccurred())
+					})
+				})
+
+				When("not running in GitHub Actions", func() {
+					BeforeEach(func() {
+						conf.GitHub.Actions = false
+					})
+
+					It("should not comment on the pull request", func() {
+						Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+						Expect(actualError).NotTo(HaveOccurred())
+					})
+				})
+
 				When("the event payload is missing", func() {
 					BeforeEach(func() {
 						conf.GitHub.EventPath = ""
@@ -369,53 +712,1186 @@ var _ = Describe("EnforcerAction", func() {
 			})
 		})
 
-		When("the resource fails evaluation", func() {
+		When("digest resolution is enabled", func() {
+			var (
+				expectedImage  string
+				expectedDigest string
+			)
+
 			BeforeEach(func() {
-				resourceEvaluationResult.ResourceEvaluation.Pass = false
+				expectedImage = fmt.Sprintf("%s/%s:%s", fake.DomainName(), fake.LetterN(10), fake.AppVersion())
+				expectedDigest = fmt.Sprintf("%s@sha256:%s", strings.Split(expectedImage, ":")[0], fakeDigest())
+
+				conf.ResourceUri = expectedImage
+				conf.Registry.ResolveDigest = true
+				resolver.digests = map[string]string{expectedImage: expectedDigest}
+				resourceEvaluationResult.ResourceEvaluation.ResourceVersion.Version = expectedDigest
 			})
 
-			It("should fail the build", func() {
-				Expect(actualResult.Pass).To(BeFalse())
-				Expect(actualResult.FailBuild).To(BeTrue())
-				Expect(actualResult.EvaluationReport).To(ContainSubstring("Resource Evaluation Report ❌ (FAILED)"))
+			It("should evaluate the resolved digest", func() {
+				Expect(actualError).NotTo(HaveOccurred())
+
+				_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(0)
+				Expect(actualRequest.ResourceUri).To(Equal(expectedDigest))
 			})
 
-			When("enforcement is disabled", func() {
+			It("should include the tag and the digest in the report", func() {
+				Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("| `%s` | `%s` |", expectedDigest, expectedImage)))
+			})
+
+			When("the resource isn't an image", func() {
 				BeforeEach(func() {
-					conf.Enforce = false
+					conf.ResourceUri = "git://github.com/rode/enforcer-action@" + fake.Regex("[a-f0-9]{40}")
 				})
 
-				It("should not fail the build", func() {
-					Expect(actualResult.Pass).To(BeFalse())
-					Expect(actualResult.FailBuild).To(BeFalse())
+				It("should not try to resolve a digest", func() {
+					Expect(resolver.calls).To(Equal(0))
+
+					_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(0)
+					Expect(actualRequest.ResourceUri).To(Equal(conf.ResourceUri))
 				})
+			})
 
-				It("should not return an error", func() {
-					Expect(actualError).NotTo(HaveOccurred())
+			When("an error occurs resolving the digest", func() {
+				BeforeEach(func() {
+					resolver.err = errors.New(fake.Word())
+				})
+
+				It("should return an error without evaluating the resource", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(HaveOccurred())
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
 				})
 			})
 		})
 
-		When("an error occurs evaluating the resource", func() {
+		When("manifests are configured", func() {
+			var (
+				expectedManifests   []string
+				discoveredImages    []*discovery.Image
+				discoveryError      error
+				actualManifests     []string
+				failingResourceUris map[string]bool
+			)
+
 			BeforeEach(func() {
-				resourceEvaluationError = errors.New(fake.Word())
+				conf.ResourceUri = ""
+				expectedManifests = []string{fake.LetterN(10), fake.LetterN(10)}
+				conf.Manifests = expectedManifests
+				discoveryError = nil
+				failingResourceUris = map[string]bool{}
+
+				source := fake.LetterN(10) + ".yaml"
+				discoveredImages = []*discovery.Image{
+					{Reference: fmt.Sprintf("%s/%s@sha256:%s", fake.DomainName(), fake.LetterN(10), fakeDigest()), Source: source},
+					{Reference: fmt.Sprintf("%s/%s:%s", fake.DomainName(), fake.LetterN(10), fake.AppVersion()), Source: source},
+				}
+
+				discoveryFindImages = func(patterns []string) ([]*discovery.Image, error) {
+					actualManifests = patterns
+
+					return discoveredImages, discoveryError
+				}
+
+				rodeClient.EvaluateResourceStub = func(_ context.Context, request *rode.ResourceEvaluationRequest, _ ...grpc.CallOption) (*rode.ResourceEvaluationResult, error) {
+					return &rode.ResourceEvaluationResult{
+						ResourceEvaluation: &rode.ResourceEvaluation{
+							Id:   fake.UUID(),
+							Pass: !failingResourceUris[request.ResourceUri],
+							ResourceVersion: &rode.ResourceVersion{
+								Version: request.ResourceUri,
+							},
+						},
+						PolicyEvaluations: resourceEvaluationResult.PolicyEvaluations,
+					}, nil
+				}
 			})
 
-			It("should return an error", func() {
-				Expect(actualResult).To(BeNil())
-				Expect(actualError).To(MatchError(ContainSubstring("error evaluating resource")))
+			AfterEach(func() {
+				discoveryFindImages = discovery.FindImages
+			})
+
+			It("should evaluate every discovered image", func() {
+				Expect(actualError).NotTo(HaveOccurred())
+				Expect(actualManifests).To(Equal(expectedManifests))
+				Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(2))
+
+				for i, image := range discoveredImages {
+					_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(i)
+					Expect(actualRequest.ResourceUri).To(Equal(image.Reference))
+					Expect(actualRequest.PolicyGroup).To(Equal(expectedPolicyGroup))
+				}
+			})
+
+			It("should aggregate the results in one report", func() {
+				Expect(actualResult.Pass).To(BeTrue())
+				Expect(actualResult.EvaluationReport).To(ContainSubstring("## Resources"))
+				for _, image := range discoveredImages {
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("| `%s` | ✅ (PASSED) |", image.Reference)))
+				}
+			})
+
+			It("should warn about images that aren't pinned by digest", func() {
+				Expect(actualResult.EvaluationReport).To(ContainSubstring("Warnings"))
+				Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("`%s` in `%s` is not pinned by digest", discoveredImages[1].Reference, discoveredImages[1].Source)))
+				Expect(actualResult.EvaluationReport).NotTo(ContainSubstring(fmt.Sprintf("`%s` in", discoveredImages[0].Reference)))
+				Expect(actualResult.Warnings).To(ConsistOf(fmt.Sprintf("%s in %s is not pinned by digest", discoveredImages[1].Reference, discoveredImages[1].Source)))
+			})
+
+			When("the resource uri is also set", func() {
+				BeforeEach(func() {
+					conf.ResourceUri = discoveredImages[0].Reference
+				})
+
+				It("should only evaluate each resource once", func() {
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(2))
+				})
+			})
+
+			When("one of the images fails evaluation", func() {
+				BeforeEach(func() {
+					failingResourceUris[discoveredImages[1].Reference] = true
+				})
+
+				It("should fail the build", func() {
+					Expect(actualResult.Pass).To(BeFalse())
+					Expect(actualResult.FailBuild).To(BeTrue())
+					Expect(actualResult.EvaluationReport).To(ContainSubstring("Resource Evaluation Report ❌ (FAILED)"))
+				})
+			})
+
+			When("no images are found", func() {
+				BeforeEach(func() {
+					discoveredImages = nil
+				})
+
+				It("should return an error", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring("no resources")))
+				})
+			})
+
+			When("an error occurs discovering images", func() {
+				BeforeEach(func() {
+					discoveryError = errors.New(fake.Word())
+				})
+
+				It("should return an error", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring("error discovering images")))
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+				})
 			})
 		})
 
-		When("an error occurs fetching the policy", func() {
+		When("build metadata is configured", func() {
+			var (
+				expectedImages []*discovery.Image
+				metadataError  error
+				actualMetadata string
+			)
+
 			BeforeEach(func() {
-				rodeClient.GetPolicyReturns(nil, errors.New("get policy error"))
+				conf.ResourceUri = ""
+				conf.BuildMetadata = fake.LetterN(10)
+				metadataError = nil
+				expectedImages = []*discovery.Image{
+					{Reference: fmt.Sprintf("%s/%s@sha256:%s", fake.DomainName(), fake.LetterN(10), fakeDigest())},
+				}
+
+				discoveryFindBuildMetadataImages = func(metadata string) ([]*discovery.Image, error) {
+					actualMetadata = metadata
+
+					return expectedImages, metadataError
+				}
 			})
 
-			It("should return an error", func() {
-				Expect(actualResult).To(BeNil())
-				Expect(actualError).To(HaveOccurred())
+			AfterEach(func() {
+				discoveryFindBuildMetadataImages = discovery.FindBuildMetadataImages
+			})
+
+			It("should evaluate the pushed image", func() {
+				Expect(actualError).NotTo(HaveOccurred())
+				Expect(actualMetadata).To(Equal(conf.BuildMetadata))
+				Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(1))
+
+				_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(0)
+				Expect(actualRequest.ResourceUri).To(Equal(expectedImages[0].Reference))
+			})
+
+			When("the metadata can't be parsed", func() {
+				BeforeEach(func() {
+					metadataError = errors.New(fake.Word())
+				})
+
+				It("should return an error", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(HaveOccurred())
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+				})
 			})
 		})
-	})
-})
+
+		When("the workflow commit is evaluated", func() {
+			var (
+				expectedSha      string
+				expectedPrNumber int
+				expectedHost     string
+				eventPayload     interface{}
+				issuesUrl        string
+			)
+
+			BeforeEach(func() {
+				expectedSha = fake.Regex("[a-f0-9]{40}")
+				expectedPrNumber = fake.Number(2, 100)
+				expectedHost = fake.DomainName()
+				eventPayload = nil
+
+				conf.ResourceUri = ""
+				conf.EvaluateCommit = true
+				conf.GitHub.ServerUrl = "https://" + expectedHost
+				conf.GitHub.Sha = fake.Regex("[a-f0-9]{40}")
+
+				osReadFile = func(name string) ([]byte, error) {
+					return json.Marshal(eventPayload)
+				}
+
+				issuesUrl = fmt.Sprintf("https://api.github.com/repos/%s/%s/issues", expectedOrg, expectedRepo)
+				httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s/%d/comments", issuesUrl, expectedPrNumber), httpmock.NewStringResponder(http.StatusOK, "[]"))
+				httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("%s/%d/comments", issuesUrl, expectedPrNumber), httpmock.NewStringResponder(http.StatusOK, "{}"))
+			})
+
+			It("should evaluate the commit that triggered the workflow", func() {
+				Expect(actualError).NotTo(HaveOccurred())
+
+				_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(0)
+				Expect(actualRequest.ResourceUri).To(Equal(fmt.Sprintf("git://%s/%s/%s@%s", expectedHost, expectedOrg, expectedRepo, conf.GitHub.Sha)))
+			})
+
+			When("a pull request triggers the workflow", func() {
+				BeforeEach(func() {
+					conf.GitHub.EventName = "pull_request"
+					conf.GitHub.EventPath = fake.LetterN(10)
+					eventPayload = &pullRequestEvent{
+						PullRequest: &pullRequest{
+							Number: expectedPrNumber,
+							Head:   &pullRequestHead{Sha: expectedSha},
+						},
+					}
+				})
+
+				It("should evaluate the head commit of the pull request", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+
+					_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(0)
+					Expect(actualRequest.ResourceUri).To(Equal(fmt.Sprintf("git://%s/%s/%s@%s", expectedHost, expectedOrg, expectedRepo, expectedSha)))
+				})
+			})
+
+			When("a pull request comment triggers the workflow", func() {
+				var pullRequestResponse *http.Response
+
+				BeforeEach(func() {
+					conf.GitHub.EventName = "issue_comment"
+					conf.GitHub.EventPath = fake.LetterN(10)
+					commentId := fake.Int64()
+					eventPayload = &issueCommentEvent{
+						Action:  "created",
+						Issue:   &issue{Number: expectedPrNumber, PullRequest: &struct{}{}},
+						Comment: &issueComment{Id: commentId, Body: "/rode evaluate", AuthorAssociation: "MEMBER"},
+					}
+
+					pullRequestResponse = httpmock.NewStringResponse(http.StatusOK, fmt.Sprintf(`{"head": {"sha": "%s"}}`, expectedSha))
+					httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d", expectedOrg, expectedRepo, expectedPrNumber), func(_ *http.Request) (*http.Response, error) {
+						return pullRequestResponse, nil
+					})
+					httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("%s/comments/%d/reactions", issuesUrl, commentId), httpmock.NewStringResponder(http.StatusOK, "{}"))
+				})
+
+				It("should evaluate the head commit of the pull request", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+
+					_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(0)
+					Expect(actualRequest.ResourceUri).To(Equal(fmt.Sprintf("git://%s/%s/%s@%s", expectedHost, expectedOrg, expectedRepo, expectedSha)))
+				})
+
+				When("an error occurs fetching the pull request", func() {
+					BeforeEach(func() {
+						pullRequestResponse = httpmock.NewStringResponse(http.StatusInternalServerError, "{}")
+					})
+
+					It("should return an error", func() {
+						Expect(actualResult).To(BeNil())
+						Expect(actualError).To(MatchError(ContainSubstring("error fetching pull request")))
+						Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+					})
+				})
+			})
+
+			When("the commit sha isn't set", func() {
+				BeforeEach(func() {
+					conf.GitHub.Sha = ""
+				})
+
+				It("should return an error", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring("unable to determine the commit")))
+				})
+			})
+		})
+
+		When("waiting for occurrences", func() {
+			var (
+				occurrencePages [][]*grafeas_go_proto.Occurrence
+				listCalls       int
+				availableAfter  int
+				listError       error
+				now             time.Time
+				sleeps          []time.Duration
+			)
+
+			BeforeEach(func() {
+				listCalls = 0
+				availableAfter = 3
+				listError = nil
+				sleeps = nil
+				now = time.Now()
+				conf.Wait = &config.WaitConfig{
+					Occurrences: []string{"vulnerability", "harbor-scan"},
+					Timeout:     time.Minute,
+					Interval:    20 * time.Second,
+				}
+
+				occurrencePages = [][]*grafeas_go_proto.Occurrence{
+					{{Kind: grafeas_common_proto.NoteKind_BUILD, NoteName: "projects/rode/notes/build"}},
+					{
+						{Kind: grafeas_common_proto.NoteKind_VULNERABILITY, NoteName: "projects/rode/notes/harbor-scan"},
+					},
+				}
+
+				rodeClient.ListVersionedResourceOccurrencesStub = func(_ context.Context, request *rode.ListVersionedResourceOccurrencesRequest, _ ...grpc.CallOption) (*rode.ListVersionedResourceOccurrencesResponse, error) {
+					listCalls++
+					// the occurrences are split across pages once they're available
+					if listCalls < availableAfter {
+						return &rode.ListVersionedResourceOccurrencesResponse{Occurrences: occurrencePages[0]}, listError
+					}
+
+					if request.PageToken == "" {
+						return &rode.ListVersionedResourceOccurrencesResponse{Occurrences: occurrencePages[0], NextPageToken: "next"}, listError
+					}
+
+					return &rode.ListVersionedResourceOccurrencesResponse{Occurrences: occurrencePages[1]}, listError
+				}
+
+				timeNow = func() time.Time {
+					return now
+				}
+				timeAfter = func(d time.Duration) <-chan time.Time {
+					sleeps = append(sleeps, d)
+					now = now.Add(d)
+					elapsed := make(chan time.Time, 1)
+					elapsed <- now
+
+					return elapsed
+				}
+			})
+
+			AfterEach(func() {
+				timeNow = time.Now
+				timeAfter = time.After
+			})
+
+			It("should poll until the occurrences are present, then evaluate the resource", func() {
+				Expect(actualError).NotTo(HaveOccurred())
+				Expect(sleeps).To(Equal([]time.Duration{20 * time.Second, 20 * time.Second}))
+				Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(1))
+
+				_, actualRequest, _ := rodeClient.ListVersionedResourceOccurrencesArgsForCall(0)
+				Expect(actualRequest.ResourceUri).To(Equal(expectedResourceUri))
+			})
+
+			It("should not report a warning", func() {
+				Expect(actualResult.EvaluationReport).NotTo(ContainSubstring("Warnings"))
+			})
+
+			When("the occurrences don't show up before the timeout", func() {
+				BeforeEach(func() {
+					conf.Wait.Timeout = 30 * time.Second
+					availableAfter = 100
+				})
+
+				It("should evaluate the resource anyway", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+					Expect(sleeps).To(Equal([]time.Duration{20 * time.Second, 10 * time.Second}))
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(1))
+				})
+
+				It("should warn about the missing occurrences", func() {
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("Timed out after 30s waiting for vulnerability, harbor-scan occurrences for `%s`", expectedResourceUri)))
+				})
+			})
+
+			When("the context is canceled while waiting", func() {
+				BeforeEach(func() {
+					canceledCtx, cancel := context.WithCancel(context.Background())
+					cancel()
+					ctx = canceledCtx
+					timeAfter = func(time.Duration) <-chan time.Time {
+						return make(chan time.Time)
+					}
+				})
+
+				AfterEach(func() {
+					ctx = context.Background()
+				})
+
+				It("should stop waiting and return an error without evaluating the resource", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring(context.Canceled.Error())))
+					Expect(listCalls).To(Equal(1))
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+				})
+			})
+
+			When("an error occurs listing occurrences", func() {
+				BeforeEach(func() {
+					listError = errors.New(fake.Word())
+				})
+
+				It("should return an error without evaluating the resource", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring("error listing occurrences")))
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		When("recording the decision", func() {
+			var (
+				batchCreateError error
+				actualDecision   *enforcementDecision
+				actualOccurrence *grafeas_go_proto.Occurrence
+			)
+
+			BeforeEach(func() {
+				batchCreateError = nil
+				actualDecision = nil
+				actualOccurrence = nil
+				conf.RecordDecision = true
+				conf.Environment = fake.LetterN(10)
+				conf.GitHub.Workflow = fake.LetterN(10)
+				conf.GitHub.Actor = fake.Username()
+
+				rodeClient.BatchCreateOccurrencesStub = func(_ context.Context, request *rode.BatchCreateOccurrencesRequest, _ ...grpc.CallOption) (*rode.BatchCreateOccurrencesResponse, error) {
+					actualOccurrence = request.Occurrences[0]
+					details := &structpb.Struct{}
+					Expect(actualOccurrence.GetDiscovered().GetDiscovered().GetAnalysisStatusError().GetDetails()[0].UnmarshalTo(details)).To(Succeed())
+					payload, _ := json.Marshal(details.AsMap())
+					actualDecision = &enforcementDecision{}
+					Expect(json.Unmarshal(payload, actualDecision)).To(Succeed())
+
+					return &rode.BatchCreateOccurrencesResponse{}, batchCreateError
+				}
+			})
+
+			It("should create a discovery occurrence for the resource", func() {
+				Expect(actualError).NotTo(HaveOccurred())
+				Expect(rodeClient.BatchCreateOccurrencesCallCount()).To(Equal(1))
+
+				Expect(actualOccurrence.Resource.Uri).To(Equal(resourceEvaluationResult.ResourceEvaluation.ResourceVersion.Version))
+				Expect(actualOccurrence.Kind).To(Equal(grafeas_common_proto.NoteKind_DISCOVERY))
+				Expect(actualOccurrence.NoteName).To(Equal(decisionNoteName))
+
+				analysisStatus := actualOccurrence.GetDiscovered().GetDiscovered().GetAnalysisStatusError()
+				Expect(codes.Code(analysisStatus.Code)).To(Equal(codes.OK))
+				Expect(analysisStatus.Message).To(Equal("allowed by policy group " + expectedPolicyGroup))
+			})
+
+			It("should not record the decision as an attestation or a deployment", func() {
+				Expect(actualOccurrence.GetAttestation()).To(BeNil())
+				Expect(actualOccurrence.GetDeployment()).To(BeNil())
+			})
+
+			It("should include the decision and the workflow context", func() {
+				Expect(actualDecision).To(Equal(&enforcementDecision{
+					Decision:     decisionAllowed,
+					Enforced:     true,
+					Pass:         true,
+					PolicyGroup:  expectedPolicyGroup,
+					EvaluationId: resourceEvaluationResult.ResourceEvaluation.Id,
+					Repository:   conf.GitHub.Repository,
+					Workflow:     conf.GitHub.Workflow,
+					RunId:        conf.GitHub.RunId,
+					RunUrl:       fmt.Sprintf("%s/%s/actions/runs/%d", conf.GitHub.ServerUrl, conf.GitHub.Repository, conf.GitHub.RunId),
+					Actor:        conf.GitHub.Actor,
+					Environment:  conf.Environment,
+				}))
+			})
+
+			When("the resource fails evaluation", func() {
+				BeforeEach(func() {
+					resourceEvaluationResult.ResourceEvaluation.Pass = false
+				})
+
+				It("should record that the build was blocked", func() {
+					analysisStatus := actualOccurrence.GetDiscovered().GetDiscovered().GetAnalysisStatusError()
+					Expect(codes.Code(analysisStatus.Code)).To(Equal(codes.FailedPrecondition))
+					Expect(actualDecision.Decision).To(Equal(decisionBlocked))
+					Expect(actualDecision.FailedBuild).To(BeTrue())
+					Expect(actualDecision.Overridden).To(BeFalse())
+				})
+
+				When("enforcement is disabled", func() {
+					BeforeEach(func() {
+						conf.Enforce = false
+					})
+
+					It("should record that the resource was allowed", func() {
+						Expect(actualDecision.Decision).To(Equal(decisionAllowed))
+						Expect(actualDecision.Enforced).To(BeFalse())
+						Expect(actualDecision.FailedBuild).To(BeFalse())
+						Expect(actualDecision.Overridden).To(BeFalse())
+					})
+				})
+			})
+
+			When("an error occurs creating the occurrence", func() {
+				BeforeEach(func() {
+					batchCreateError = errors.New(fake.Word())
+				})
+
+				It("should return an error", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring("error recording enforcement decision")))
+				})
+			})
+		})
+
+		When("uploading build provenance", func() {
+			var (
+				expectedDigest    string
+				expectedStartTime time.Time
+				batchCreateError  error
+				actualOccurrence  *grafeas_go_proto.Occurrence
+			)
+
+			BeforeEach(func() {
+				expectedDigest = strings.Split(expectedResourceUri, "@")[1]
+				expectedStartTime = time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)
+				batchCreateError = nil
+				actualOccurrence = nil
+
+				conf.UploadProvenance = true
+				conf.ResourceUriBuilt = true
+				conf.GitHub.Sha = fake.Regex("[a-f0-9]{40}")
+				conf.GitHub.Actor = fake.Username()
+
+				httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("https://api.github.com/repos/%s/%s/actions/runs/%d", expectedOrg, expectedRepo, conf.GitHub.RunId),
+					httpmock.NewStringResponder(http.StatusOK, fmt.Sprintf(`{"created_at": "%s"}`, expectedStartTime.Format(time.RFC3339))))
+
+				rodeClient.BatchCreateOccurrencesStub = func(_ context.Context, request *rode.BatchCreateOccurrencesRequest, _ ...grpc.CallOption) (*rode.BatchCreateOccurrencesResponse, error) {
+					actualOccurrence = request.Occurrences[0]
+
+					return &rode.BatchCreateOccurrencesResponse{}, batchCreateError
+				}
+			})
+
+			It("should create a build occurrence for the resource before evaluating", func() {
+				Expect(actualError).NotTo(HaveOccurred())
+				Expect(rodeClient.BatchCreateOccurrencesCallCount()).To(Equal(1))
+				Expect(actualOccurrence.Resource.Uri).To(Equal(expectedResourceUri))
+				Expect(actualOccurrence.Kind).To(Equal(grafeas_common_proto.NoteKind_BUILD))
+				Expect(actualOccurrence.NoteName).To(Equal(buildNoteName))
+			})
+
+			It("should describe the workflow run", func() {
+				provenance := actualOccurrence.GetBuild().Provenance
+
+				Expect(provenance.Id).To(Equal(strconv.Itoa(conf.GitHub.RunId)))
+				Expect(provenance.Creator).To(Equal(conf.GitHub.Actor))
+				Expect(provenance.LogsUri).To(Equal(fmt.Sprintf("%s/%s/actions/runs/%d", conf.GitHub.ServerUrl, conf.GitHub.Repository, conf.GitHub.RunId)))
+				Expect(provenance.BuilderVersion).To(Equal(githubActionsBuilder))
+				Expect(provenance.StartTime.AsTime()).To(Equal(expectedStartTime))
+				Expect(provenance.BuiltArtifacts[0].Checksum).To(Equal(expectedDigest))
+
+				git := provenance.SourceProvenance.Context.GetGit()
+				Expect(git.Url).To(Equal(fmt.Sprintf("%s/%s", conf.GitHub.ServerUrl, conf.GitHub.Repository)))
+				Expect(git.RevisionId).To(Equal(conf.GitHub.Sha))
+			})
+
+			When("a provenance file is configured", func() {
+				var provenanceFile string
+
+				BeforeEach(func() {
+					conf.ProvenanceFile = fake.LetterN(10)
+					provenanceFile = fmt.Sprintf(`{
+						"_type": "https://in-toto.io/Statement/v0.1",
+						"predicateType": "https://slsa.dev/provenance/v0.1",
+						"subject": [{"name": "app", "digest": {"sha256": "%s"}}],
+						"predicate": {
+							"builder": {"id": "https://github.com/rode/builder@v1"},
+							"metadata": {"buildFinishedOn": "2021-08-01T10:05:00Z"},
+							"materials": [{"uri": "git+https://github.com/rode/app", "digest": {"sha1": "abc123"}}]
+						}
+					}`, strings.TrimPrefix(expectedDigest, "sha256:"))
+
+					osReadFile = func(name string) ([]byte, error) {
+						if name != conf.ProvenanceFile {
+							return nil, fmt.Errorf("wrong file name")
+						}
+
+						return []byte(provenanceFile), nil
+					}
+				})
+
+				It("should include the provenance from the file", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+
+					build := actualOccurrence.GetBuild()
+					Expect(build.ProvenanceBytes).To(Equal(provenanceFile))
+					Expect(build.Provenance.BuilderVersion).To(Equal("https://github.com/rode/builder@v1"))
+					Expect(build.Provenance.EndTime.AsTime()).To(Equal(time.Date(2021, 8, 1, 10, 5, 0, 0, time.UTC)))
+					Expect(build.Provenance.SourceProvenance.Context.GetGit().RevisionId).To(Equal("abc123"))
+				})
+
+				When("the provenance is for a different artifact", func() {
+					BeforeEach(func() {
+						provenanceFile = strings.ReplaceAll(provenanceFile, strings.TrimPrefix(expectedDigest, "sha256:"), fakeDigest())
+					})
+
+					It("should return an error without evaluating", func() {
+						Expect(actualResult).To(BeNil())
+						Expect(actualError).To(MatchError(ContainSubstring("doesn't have a subject with the digest")))
+						Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+					})
+				})
+			})
+
+			When("the resource uri isn't attributed to the workflow run", func() {
+				BeforeEach(func() {
+					conf.ResourceUriBuilt = false
+				})
+
+				It("should not upload provenance for the resource", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+					Expect(rodeClient.BatchCreateOccurrencesCallCount()).To(Equal(0))
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(1))
+				})
+			})
+
+			When("an error occurs creating the occurrence", func() {
+				BeforeEach(func() {
+					batchCreateError = errors.New(fake.Word())
+				})
+
+				It("should return an error without evaluating", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring("error uploading build provenance")))
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		When("a VSA signing key is configured", func() {
+			var (
+				signingKey *ecdsa.PrivateKey
+				now        time.Time
+			)
+
+			BeforeEach(func() {
+				signingKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				der, _ := x509.MarshalECPrivateKey(signingKey)
+				keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+				now = time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)
+
+				conf.Vsa = &config.VsaConfig{SigningKey: fake.LetterN(10), VerifiedLevels: []string{"SLSA_BUILD_LEVEL_2"}}
+				conf.ClientConfig = &common.ClientConfig{Rode: &common.RodeClientConfig{Host: "rode:50051"}}
+				resourceEvaluationResult.ResourceEvaluation.ResourceVersion.Version = expectedResourceUri
+
+				osReadFile = func(name string) ([]byte, error) {
+					if name != conf.Vsa.SigningKey {
+						return nil, fmt.Errorf("wrong file name")
+					}
+
+					return keyPem, nil
+				}
+				timeNow = func() time.Time {
+					return now
+				}
+			})
+
+			AfterEach(func() {
+				timeNow = time.Now
+			})
+
+			It("should return a signed VSA for the resource", func() {
+				Expect(actualError).NotTo(HaveOccurred())
+				Expect(strings.Count(actualResult.VerificationSummaries, "\n")).To(Equal(1))
+
+				envelope := &intoto.Envelope{}
+				Expect(json.Unmarshal([]byte(actualResult.VerificationSummaries), envelope)).To(Succeed())
+				Expect(envelope.Verify(signingKey.Public())).To(Succeed())
+
+				payload, _ := envelope.DecodePayload()
+				statement, err := intoto.ParseStatement(payload)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(statement.Type).To(Equal(intoto.StatementTypeV1))
+				Expect(statement.PredicateType).To(Equal(intoto.VerificationSummaryV1))
+				Expect(statement.Subject).To(ConsistOf(&intoto.Subject{
+					Name:   strings.Split(expectedResourceUri, "@")[0],
+					Digest: map[string]string{"sha256": strings.TrimPrefix(strings.Split(expectedResourceUri, "@")[1], "sha256:")},
+				}))
+
+				summary := &intoto.VerificationSummary{}
+				Expect(json.Unmarshal(statement.Predicate, summary)).To(Succeed())
+				Expect(summary).To(Equal(&intoto.VerificationSummary{
+					Verifier:           &intoto.Verifier{Id: verifierId, Version: map[string]string{verifierName: version}},
+					TimeVerified:       now,
+					ResourceUri:        expectedResourceUri,
+					Policy:             &intoto.VerificationPolicy{Uri: "rode://rode:50051/policy-groups/" + expectedPolicyGroup},
+					VerificationResult: intoto.VerificationPassed,
+					VerifiedLevels:     []string{"SLSA_BUILD_LEVEL_2"},
+				}))
+			})
+
+			When("the resource fails evaluation", func() {
+				BeforeEach(func() {
+					resourceEvaluationResult.ResourceEvaluation.Pass = false
+				})
+
+				It("should only report the failure in the verified levels", func() {
+					envelope := &intoto.Envelope{}
+					Expect(json.Unmarshal([]byte(actualResult.VerificationSummaries), envelope)).To(Succeed())
+					payload, _ := envelope.DecodePayload()
+					statement, _ := intoto.ParseStatement(payload)
+
+					summary := &intoto.VerificationSummary{}
+					Expect(json.Unmarshal(statement.Predicate, summary)).To(Succeed())
+					Expect(summary.VerificationResult).To(Equal(intoto.VerificationFailed))
+					Expect(summary.VerifiedLevels).To(ConsistOf(intoto.VerificationFailed))
+				})
+			})
+
+			When("the resource doesn't have a digest", func() {
+				BeforeEach(func() {
+					resourceEvaluationResult.ResourceEvaluation.ResourceVersion.Version = "npm://" + fake.LetterN(10) + ":1.0.0"
+				})
+
+				It("should skip the resource", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+					Expect(actualResult.VerificationSummaries).To(BeEmpty())
+				})
+			})
+
+			When("the signing key is invalid", func() {
+				BeforeEach(func() {
+					osReadFile = func(_ string) ([]byte, error) {
+						return []byte(fake.LetterN(10)), nil
+					}
+				})
+
+				It("should return an error", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring("signing key")))
+				})
+			})
+		})
+
+		When("a baseline resource is configured", func() {
+			var (
+				expectedBaselineResourceUri string
+				baselineEvaluationResult    *rode.ResourceEvaluationResult
+			)
+
+			BeforeEach(func() {
+				expectedBaselineResourceUri = fakeImageDigestUri()
+				conf.BaselineResourceUri = expectedBaselineResourceUri
+
+				baselineEvaluationResult = &rode.ResourceEvaluationResult{
+					ResourceEvaluation: &rode.ResourceEvaluation{
+						Id:   fake.UUID(),
+						Pass: true,
+						ResourceVersion: &rode.ResourceVersion{
+							Version: expectedBaselineResourceUri,
+						},
+					},
+				}
+
+				for _, evaluation := range resourceEvaluationResult.PolicyEvaluations {
+					evaluation.Pass = true
+					baselineEvaluationResult.PolicyEvaluations = append(baselineEvaluationResult.PolicyEvaluations, &rode.PolicyEvaluation{
+						Id:              fake.UUID(),
+						Pass:            true,
+						PolicyVersionId: evaluation.PolicyVersionId,
+					})
+				}
+
+				rodeClient.EvaluateResourceReturnsOnCall(1, baselineEvaluationResult, nil)
+			})
+
+			It("should evaluate the baseline against the same policy group", func() {
+				Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(2))
+
+				_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(1)
+				Expect(actualRequest.ResourceUri).To(Equal(expectedBaselineResourceUri))
+				Expect(actualRequest.PolicyGroup).To(Equal(expectedPolicyGroup))
+			})
+
+			It("should only fetch each policy once", func() {
+				Expect(rodeClient.GetPolicyCallCount()).To(Equal(policyEvaluationsCount))
+			})
+
+			It("should include a comparison in the report", func() {
+				Expect(actualResult.EvaluationReport).To(ContainSubstring("Baseline Comparison"))
+				Expect(actualResult.EvaluationReport).To(ContainSubstring(baselineEvaluationResult.ResourceEvaluation.Id))
+				Expect(actualResult.EvaluationReport).To(ContainSubstring(expectedBaselineResourceUri))
+			})
+
+			When("a policy regresses", func() {
+				var regressedPolicy string
+
+				BeforeEach(func() {
+					resourceEvaluationResult.ResourceEvaluation.Pass = false
+					resourceEvaluationResult.PolicyEvaluations[0].Pass = false
+					regressedPolicy = expectedPolicyNames[resourceEvaluationResult.PolicyEvaluations[0].PolicyVersionId]
+				})
+
+				It("should mark the regression in the report", func() {
+					Expect(actualResult.EvaluationReport).To(ContainSubstring(fmt.Sprintf("| %s | ✅ (PASSED) | ❌ (FAILED) | ⚠️ regression |", regressedPolicy)))
+				})
+
+				When("the build should only fail on regressions", func() {
+					BeforeEach(func() {
+						conf.FailOnRegression = true
+					})
+
+					It("should fail the build", func() {
+						Expect(actualResult.Pass).To(BeFalse())
+						Expect(actualResult.FailBuild).To(BeTrue())
+					})
+				})
+			})
+
+			When("a policy fails for both the baseline and the candidate", func() {
+				BeforeEach(func() {
+					conf.FailOnRegression = true
+					resourceEvaluationResult.ResourceEvaluation.Pass = false
+					resourceEvaluationResult.PolicyEvaluations[0].Pass = false
+					baselineEvaluationResult.ResourceEvaluation.Pass = false
+					baselineEvaluationResult.PolicyEvaluations[0].Pass = false
+				})
+
+				It("should not fail the build", func() {
+					Expect(actualResult.Pass).To(BeFalse())
+					Expect(actualResult.FailBuild).To(BeFalse())
+				})
+			})
+
+			When("an error occurs evaluating the baseline", func() {
+				BeforeEach(func() {
+					rodeClient.EvaluateResourceReturnsOnCall(1, nil, errors.New(fake.Word()))
+				})
+
+				It("should return an error", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring("error evaluating resource")))
+				})
+			})
+		})
+
+		When("a pull request comment triggers the workflow", func() {
+			var (
+				expectedPrNumber  int
+				expectedCommentId int64
+				commentBody       string
+				commentAction     string
+				authorAssociation string
+				isPullRequest     bool
+
+				reactionRequest  *http.Request
+				reactionResponse *http.Response
+			)
+
+			BeforeEach(func() {
+				reactionRequest = nil
+				expectedPrNumber = fake.Number(2, 100)
+				expectedCommentId = fake.Int64()
+				commentBody = fmt.Sprintf("%s\n/rode evaluate", fake.Sentence(5))
+				commentAction = "created"
+				authorAssociation = "COLLABORATOR"
+				isPullRequest = true
+
+				conf.GitHub.EventName = "issue_comment"
+				conf.GitHub.EventPath = fmt.Sprintf("/%s/%s/event.json", fake.LetterN(10), fake.LetterN(10))
+
+				osReadFile = func(name string) ([]byte, error) {
+					if name != conf.GitHub.EventPath {
+						return nil, fmt.Errorf("wrong file name")
+					}
+
+					event := &issueCommentEvent{
+						Action: commentAction,
+						Issue:  &issue{Number: expectedPrNumber},
+						Comment: &issueComment{
+							Id:                expectedCommentId,
+							Body:              commentBody,
+							AuthorAssociation: authorAssociation,
+						},
+					}
+					if isPullRequest {
+						event.Issue.PullRequest = &struct{}{}
+					}
+
+					return json.Marshal(event)
+				}
+
+				reactionResponse = &http.Response{StatusCode: http.StatusOK}
+				baseUrl := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues", expectedOrg, expectedRepo)
+				httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s/%d/comments", baseUrl, expectedPrNumber), httpmock.NewStringResponder(http.StatusOK, "[]"))
+				httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("%s/%d/comments", baseUrl, expectedPrNumber), httpmock.NewStringResponder(http.StatusOK, "{}"))
+				httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("%s/comments/%d/reactions", baseUrl, expectedCommentId), func(request *http.Request) (*http.Response, error) {
+					reactionRequest = request
+
+					return reactionResponse, nil
+				})
+			})
+
+			It("should evaluate the resource against the configured policy group", func() {
+				Expect(actualError).NotTo(HaveOccurred())
+				Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(1))
+
+				_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(0)
+				Expect(actualRequest.PolicyGroup).To(Equal(expectedPolicyGroup))
+			})
+
+			It("should react to the comment with a thumbs up", func() {
+				Expect(reactionRequest).NotTo(BeNil())
+
+				body, err := io.ReadAll(reactionRequest.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(body)).To(ContainSubstring(`"content":"+1"`))
+			})
+
+			It("should update the pull request comment", func() {
+				Expect(httpmock.GetTotalCallCount()).To(Equal(3))
+			})
+
+			When("the command specifies a policy group", func() {
+				var commandPolicyGroup string
+
+				BeforeEach(func() {
+					commandPolicyGroup = fake.LetterN(10)
+					commentBody = "/rode evaluate " + commandPolicyGroup
+					conf.CommandPolicyGroups = []string{fake.LetterN(10), commandPolicyGroup}
+				})
+
+				It("should evaluate the resource against that policy group", func() {
+					_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(0)
+					Expect(actualRequest.PolicyGroup).To(Equal(commandPolicyGroup))
+				})
+
+				When("the policy group isn't in command-policy-groups", func() {
+					BeforeEach(func() {
+						conf.CommandPolicyGroups = nil
+					})
+
+					It("should return an error without evaluating", func() {
+						Expect(actualResult).To(BeNil())
+						Expect(actualError).To(MatchError(ContainSubstring(fmt.Sprintf("policy group %q can't be selected by an evaluate command", commandPolicyGroup))))
+						Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+					})
+
+					It("should react to the comment with a thumbs down", func() {
+						Expect(reactionRequest).NotTo(BeNil())
+
+						body, err := io.ReadAll(reactionRequest.Body)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(string(body)).To(ContainSubstring(`"content":"-1"`))
+					})
+				})
+			})
+
+			When("the comment author isn't an owner, member or collaborator", func() {
+				BeforeEach(func() {
+					authorAssociation = "NONE"
+				})
+
+				It("should skip the evaluation", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+					Expect(actualResult.Skipped).To(BeTrue())
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+					Expect(reactionRequest).To(BeNil())
+				})
+			})
+
+			When("an error occurs evaluating the resource", func() {
+				BeforeEach(func() {
+					resourceEvaluationError = errors.New(fake.Word())
+				})
+
+				It("should return the error and react to the comment with a thumbs down", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(MatchError(ContainSubstring("error evaluating resource")))
+
+					body, err := io.ReadAll(reactionRequest.Body)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(body)).To(ContainSubstring(`"content":"-1"`))
+				})
+			})
+
+			When("the evaluation fails", func() {
+				BeforeEach(func() {
+					resourceEvaluationResult.ResourceEvaluation.Pass = false
+				})
+
+				It("should react to the comment with a thumbs down", func() {
+					body, err := io.ReadAll(reactionRequest.Body)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(body)).To(ContainSubstring(`"content":"-1"`))
+				})
+			})
+
+			When("the comment does not contain a command", func() {
+				BeforeEach(func() {
+					commentBody = fake.Sentence(5)
+				})
+
+				It("should skip the evaluation", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+					Expect(actualResult.Skipped).To(BeTrue())
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+				})
+			})
+
+			When("the comment was edited", func() {
+				BeforeEach(func() {
+					commentAction = "edited"
+				})
+
+				It("should skip the evaluation", func() {
+					Expect(actualResult.Skipped).To(BeTrue())
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+				})
+			})
+
+			When("the comment is on an issue", func() {
+				BeforeEach(func() {
+					isPullRequest = false
+				})
+
+				It("should skip the evaluation", func() {
+					Expect(actualResult.Skipped).To(BeTrue())
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+				})
+			})
+
+			When("the action isn't running in GitHub Actions", func() {
+				BeforeEach(func() {
+					conf.GitHub.Actions = false
+				})
+
+				It("should evaluate without reacting to the comment", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+					Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(1))
+					Expect(reactionRequest).To(BeNil())
+				})
+			})
+
+			When("an error occurs reacting to the comment", func() {
+				BeforeEach(func() {
+					reactionResponse.StatusCode = http.StatusInternalServerError
+				})
+
+				It("should return an error", func() {
+					Expect(actualResult).To(BeNil())
+					Expect(actualError).To(HaveOccurred())
+				})
+			})
+		})
+
+		When("the resource fails evaluation", func() {
+			BeforeEach(func() {
+				resourceEvaluationResult.ResourceEvaluation.Pass = false
+			})
+
+			It("should fail the build", func() {
+				Expect(actualResult.Pass).To(BeFalse())
+				Expect(actualResult.FailBuild).To(BeTrue())
+				Expect(actualResult.EvaluationReport).To(ContainSubstring("Resource Evaluation Report ❌ (FAILED)"))
+			})
+
+			When("enforcement is disabled", func() {
+				BeforeEach(func() {
+					conf.Enforce = false
+				})
+
+				It("should not fail the build", func() {
+					Expect(actualResult.Pass).To(BeFalse())
+					Expect(actualResult.FailBuild).To(BeFalse())
+				})
+
+				It("should not return an error", func() {
+					Expect(actualError).NotTo(HaveOccurred())
+				})
+			})
+		})
+
+		When("an error occurs evaluating the resource", func() {
+			BeforeEach(func() {
+				resourceEvaluationError = errors.New(fake.Word())
+			})
+
+			It("should return an error", func() {
+				Expect(actualResult).To(BeNil())
+				Expect(actualError).To(MatchError(ContainSubstring("error evaluating resource")))
+			})
+		})
+
+		When("the resource uri is malformed", func() {
+			BeforeEach(func() {
+				conf.ResourceUri = "npm://" + fake.LetterN(10)
+			})
+
+			It("should return an error without evaluating the resource", func() {
+				Expect(actualResult).To(BeNil())
+				Expect(actualError).To(MatchError(ContainSubstring("invalid resource uri")))
+				Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+			})
+		})
+
+		When("an error occurs fetching the policy", func() {
+			BeforeEach(func() {
+				rodeClient.GetPolicyReturns(nil, errors.New("get policy error"))
+			})
+
+			It("should return an error", func() {
+				Expect(actualResult).To(BeNil())
+				Expect(actualError).To(HaveOccurred())
+			})
+		})
+	})
+})
+
+type fakeDigestResolver struct {
+	digests map[string]string
+	err     error
+	calls   int
+}
+
+func (f *fakeDigestResolver) ResolveDigest(_ context.Context, image string) (string, error) {
+	f.calls++
+	if f.err != nil {
+		return "", f.err
+	}
+
+	return f.digests[image], nil
+}
+
+func fakeDigest() string {
+	return fake.Regex("[a-f0-9]{64}")
+}
+
+func fakeImageDigestUri() string {
+	return fmt.Sprintf("%s/%s@sha256:%s", fake.DomainName(), fake.LetterN(10), fakeDigest())
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"sort"
+
+	rode "github.com/rode/rode/proto/v1alpha1"
+)
+
+const notEvaluatedMessage = "➖ (NOT EVALUATED)"
+
+type policyComparison struct {
+	name      string
+	baseline  *bool
+	candidate *bool
+}
+
+// regressed is true when a policy passed for the baseline but didn't pass for the candidate
+func (p *policyComparison) regressed() bool {
+	return p.baseline != nil && *p.baseline && (p.candidate == nil || !*p.candidate)
+}
+
+type baselineComparison struct {
+	baseline *rode.ResourceEvaluation
+	policies []*policyComparison
+}
+
+func (a *EnforcerAction) compareToBaseline(ctx context.Context, baseline, candidate *rode.ResourceEvaluationResult) (*baselineComparison, error) {
+	policiesByName := map[string]*policyComparison{}
+	comparePolicies := func(result *rode.ResourceEvaluationResult, setResult func(*policyComparison, bool)) error {
+		for _, evaluation := range result.PolicyEvaluations {
+			policy, err := a.getPolicy(ctx, evaluation.PolicyVersionId)
+			if err != nil {
+				return err
+			}
+
+			comparison, ok := policiesByName[policy.Name]
+			if !ok {
+				comparison = &policyComparison{name: policy.Name}
+				policiesByName[policy.Name] = comparison
+			}
+			setResult(comparison, evaluation.Pass)
+		}
+
+		return nil
+	}
+
+	if err := comparePolicies(baseline, func(p *policyComparison, pass bool) { p.baseline = &pass }); err != nil {
+		return nil, err
+	}
+
+	if err := comparePolicies(candidate, func(p *policyComparison, pass bool) { p.candidate = &pass }); err != nil {
+		return nil, err
+	}
+
+	comparison := &baselineComparison{baseline: baseline.ResourceEvaluation}
+	for _, policy := range policiesByName {
+		comparison.policies = append(comparison.policies, policy)
+	}
+	sort.Slice(comparison.policies, func(i, j int) bool {
+		return comparison.policies[i].name < comparison.policies[j].name
+	})
+
+	return comparison, nil
+}
+
+func (c *baselineComparison) hasRegressions() bool {
+	for _, policy := range c.policies {
+		if policy.regressed() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *EnforcerAction) renderBaselineComparison(md *markdownPrinter, c *baselineComparison, depth int) {
+	var rows [][]string
+	for _, policy := range c.policies {
+		regression := ""
+		if policy.regressed() {
+			regression = "⚠️ regression"
+		}
+
+		rows = append(rows, []string{policy.name, comparisonStatus(policy.baseline), comparisonStatus(policy.candidate), regression})
+	}
+
+	metadataHeaders, metadata := a.resourceMetadata(c.baseline.ResourceVersion.Version)
+	md.
+		header(depth, "Baseline Comparison").
+		quote("baseline report id: "+c.baseline.Id).
+		newline().
+		table(metadataHeaders, [][]string{metadata}).
+		table([]string{"Policy", "Baseline", "Candidate", ""}, rows)
+}
+
+func comparisonStatus(pass *bool) string {
+	if pass == nil {
+		return notEvaluatedMessage
+	}
+
+	return statusMessage(*pass)
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	rode "github.com/rode/rode/proto/v1alpha1"
+)
+
+const policiesPageSize = 100
+
+// AssignedPolicy is a policy version assigned to a policy group
+type AssignedPolicy struct {
+	Name            string `json:"name"`
+	PolicyId        string `json:"policyId"`
+	PolicyVersionId string `json:"policyVersionId"`
+	Version         uint32 `json:"version"`
+}
+
+type PolicyGroup struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Report renders an existing resource evaluation in the same formats as Run. The stored result is shown as is, since
+// min-policies, required-policies and policy-versions may have changed since the evaluation ran.
+func (a *EnforcerAction) Report(ctx context.Context, evaluationId string) (*ActionResult, error) {
+	result, err := a.client.GetResourceEvaluation(ctx, &rode.GetResourceEvaluationRequest{Id: evaluationId})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching resource evaluation %s: %s", evaluationId, err)
+	}
+
+	resourceEval := result.ResourceEvaluation
+	evaluation := &resourceEvaluation{
+		target: &target{uri: resourceEval.ResourceVersion.Version},
+		result: result,
+	}
+	evaluations := []*resourceEvaluation{evaluation}
+
+	report, _, err := a.createEvaluationReport(ctx, evaluations)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := a.collectResults(ctx, evaluations)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActionResult{
+		Pass:             evaluation.pass(),
+		PolicyGroup:      resourceEval.PolicyGroup,
+		Resources:        results,
+		EvaluationReport: report,
+	}, nil
+}
+
+// AssignedPolicies lists the policy versions assigned to a policy group, along with the policy names
+func (a *EnforcerAction) AssignedPolicies(ctx context.Context, policyGroup string) ([]*AssignedPolicy, error) {
+	assignments, err := a.policyAssignments(ctx, policyGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []*AssignedPolicy{}
+	for _, assignment := range assignments {
+		policy, err := a.getPolicy(ctx, assignment.PolicyVersionId)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching policy %s: %s", assignment.PolicyVersionId, err)
+		}
+
+		policies = append(policies, &AssignedPolicy{
+			Name:            policy.Name,
+			PolicyId:        policy.Id,
+			PolicyVersionId: assignment.PolicyVersionId,
+			Version:         policy.GetPolicy().GetVersion(),
+		})
+	}
+
+	return policies, nil
+}
+
+// PolicyGroups lists every policy group in Rode
+func (a *EnforcerAction) PolicyGroups(ctx context.Context) ([]*PolicyGroup, error) {
+	groups := []*PolicyGroup{}
+	pageToken := ""
+	for {
+		response, err := a.client.ListPolicyGroups(ctx, &rode.ListPolicyGroupsRequest{
+			PageSize:  policiesPageSize,
+			PageToken: pageToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing policy groups: %s", err)
+		}
+
+		for _, group := range response.PolicyGroups {
+			groups = append(groups, &PolicyGroup{Name: group.Name, Description: group.Description})
+		}
+
+		pageToken = response.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rode/enforcer-action/config"
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"github.com/rode/rode/proto/v1alpha1fakes"
+	"google.golang.org/grpc"
+)
+
+var _ = Describe("commands", func() {
+	var (
+		ctx        = context.Background()
+		rodeClient *v1alpha1fakes.FakeRodeClient
+		action     *EnforcerAction
+	)
+
+	BeforeEach(func() {
+		rodeClient = &v1alpha1fakes.FakeRodeClient{}
+		rodeClient.GetPolicyStub = func(_ context.Context, request *rode.GetPolicyRequest, _ ...grpc.CallOption) (*rode.Policy, error) {
+			return &rode.Policy{
+				Id:     "policy-" + request.Id,
+				Name:   "name-" + request.Id,
+				Policy: &rode.PolicyEntity{Id: request.Id, Version: 3},
+			}, nil
+		}
+
+		conf := &config.Config{
+			Command: config.CommandReport,
+			GitHub:  &config.GitHubConfig{},
+		}
+		action = NewEnforcerAction(logger, conf, rodeClient, nil, nil)
+	})
+
+	Context("Report", func() {
+		var (
+			evaluationId string
+			evaluation   *rode.ResourceEvaluationResult
+		)
+
+		BeforeEach(func() {
+			evaluationId = fake.UUID()
+			evaluation = &rode.ResourceEvaluationResult{
+				ResourceEvaluation: &rode.ResourceEvaluation{
+					Id:          evaluationId,
+					Pass:        false,
+					PolicyGroup: fake.Word(),
+					ResourceVersion: &rode.ResourceVersion{
+						Version: fakeImageDigestUri(),
+					},
+				},
+				PolicyEvaluations: []*rode.PolicyEvaluation{
+					{
+						PolicyVersionId: "a.1",
+						Pass:            false,
+						Violations: []*rode.EvaluatePolicyViolation{
+							{Message: fake.Sentence(3)},
+						},
+					},
+				},
+			}
+			rodeClient.GetResourceEvaluationReturns(evaluation, nil)
+		})
+
+		It("should render the evaluation", func() {
+			result, err := action.Report(ctx, evaluationId)
+
+			Expect(err).NotTo(HaveOccurred())
+			_, actualRequest, _ := rodeClient.GetResourceEvaluationArgsForCall(0)
+			Expect(actualRequest.Id).To(Equal(evaluationId))
+
+			Expect(result.Pass).To(BeFalse())
+			Expect(result.FailBuild).To(BeFalse())
+			Expect(result.PolicyGroup).To(Equal(evaluation.ResourceEvaluation.PolicyGroup))
+			Expect(result.EvaluationReport).To(ContainSubstring("Resource Evaluation Report ❌ (FAILED)"))
+			Expect(result.EvaluationReport).To(ContainSubstring("report id: " + evaluationId))
+			Expect(result.EvaluationReport).To(ContainSubstring("name-a.1"))
+			Expect(result.Resources).To(HaveLen(1))
+			Expect(result.Resources[0].Policies).To(ConsistOf(&PolicyResult{
+				Name:            "name-a.1",
+				PolicyVersionId: "a.1",
+				Pass:            false,
+				Violations:      []string{evaluation.PolicyEvaluations[0].Violations[0].Message},
+			}))
+		})
+
+		It("should show the stored result without applying the current policy requirements", func() {
+			evaluation.ResourceEvaluation.Pass = true
+			evaluation.PolicyEvaluations[0].Pass = true
+			evaluation.PolicyEvaluations[0].Violations = nil
+			action.config.MinPolicies = 2
+			action.config.RequiredPolicies = []string{fake.Word()}
+
+			result, err := action.Report(ctx, evaluationId)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Pass).To(BeTrue())
+			Expect(result.EvaluationReport).To(ContainSubstring("Resource Evaluation Report ✅ (PASSED)"))
+			Expect(result.EvaluationReport).NotTo(ContainSubstring("required"))
+		})
+
+		It("should return an error when the evaluation can't be fetched", func() {
+			rodeClient.GetResourceEvaluationReturns(nil, errors.New(fake.Word()))
+
+			result, err := action.Report(ctx, evaluationId)
+
+			Expect(err).To(MatchError(ContainSubstring("error fetching resource evaluation " + evaluationId)))
+			Expect(result).To(BeNil())
+		})
+	})
+
+	Context("AssignedPolicies", func() {
+		var policyGroup string
+
+		BeforeEach(func() {
+			policyGroup = fake.Word()
+			rodeClient.ListPolicyAssignmentsReturnsOnCall(0, &rode.ListPolicyAssignmentsResponse{
+				PolicyAssignments: []*rode.PolicyAssignment{
+					{PolicyVersionId: "a.3", PolicyGroup: policyGroup},
+				},
+				NextPageToken: "next",
+			}, nil)
+			rodeClient.ListPolicyAssignmentsReturnsOnCall(1, &rode.ListPolicyAssignmentsResponse{
+				PolicyAssignments: []*rode.PolicyAssignment{
+					{PolicyVersionId: "b.3", PolicyGroup: policyGroup},
+				},
+			}, nil)
+		})
+
+		It("should list the policies assigned to the group across pages", func() {
+			policies, err := action.AssignedPolicies(ctx, policyGroup)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policies).To(Equal([]*AssignedPolicy{
+				{Name: "name-a.3", PolicyId: "policy-a.3", PolicyVersionId: "a.3", Version: 3},
+				{Name: "name-b.3", PolicyId: "policy-b.3", PolicyVersionId: "b.3", Version: 3},
+			}))
+
+			Expect(rodeClient.ListPolicyAssignmentsCallCount()).To(Equal(2))
+			_, firstRequest, _ := rodeClient.ListPolicyAssignmentsArgsForCall(0)
+			Expect(firstRequest.PolicyGroup).To(Equal(policyGroup))
+			Expect(firstRequest.PageToken).To(BeEmpty())
+			_, secondRequest, _ := rodeClient.ListPolicyAssignmentsArgsForCall(1)
+			Expect(secondRequest.PageToken).To(Equal("next"))
+		})
+
+		It("should return an error when the assignments can't be listed", func() {
+			rodeClient.ListPolicyAssignmentsReturnsOnCall(0, nil, errors.New(fake.Word()))
+
+			_, err := action.AssignedPolicies(ctx, policyGroup)
+
+			Expect(err).To(MatchError(ContainSubstring("error listing policy assignments for " + policyGroup)))
+		})
+
+		It("should return an error when a policy can't be fetched", func() {
+			rodeClient.GetPolicyReturns(nil, errors.New(fake.Word()))
+			rodeClient.GetPolicyStub = nil
+
+			_, err := action.AssignedPolicies(ctx, policyGroup)
+
+			Expect(err).To(MatchError(ContainSubstring("error fetching policy a.3")))
+		})
+	})
+
+	Context("PolicyGroups", func() {
+		It("should list every policy group", func() {
+			rodeClient.ListPolicyGroupsReturnsOnCall(0, &rode.ListPolicyGroupsResponse{
+				PolicyGroups:  []*rode.PolicyGroup{{Name: "dev", Description: "Development"}},
+				NextPageToken: "next",
+			}, nil)
+			rodeClient.ListPolicyGroupsReturnsOnCall(1, &rode.ListPolicyGroupsResponse{
+				PolicyGroups: []*rode.PolicyGroup{{Name: "prod", Description: "Production"}},
+			}, nil)
+
+			groups, err := action.PolicyGroups(ctx)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groups).To(Equal([]*PolicyGroup{
+				{Name: "dev", Description: "Development"},
+				{Name: "prod", Description: "Production"},
+			}))
+			_, secondRequest, _ := rodeClient.ListPolicyGroupsArgsForCall(1)
+			Expect(secondRequest.PageToken).To(Equal("next"))
+		})
+
+		It("should return an error when the groups can't be listed", func() {
+			rodeClient.ListPolicyGroupsReturns(nil, errors.New(fake.Word()))
+
+			_, err := action.PolicyGroups(ctx)
+
+			Expect(err).To(MatchError(ContainSubstring("error listing policy groups")))
+		})
+	})
+})
@@ -0,0 +1,153 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	rode "github.com/rode/rode/proto/v1alpha1"
+	grafeas_common_proto "github.com/rode/rode/protodeps/grafeas/proto/v1beta1/common_go_proto"
+	"github.com/rode/rode/protodeps/grafeas/proto/v1beta1/discovery_go_proto"
+	"github.com/rode/rode/protodeps/grafeas/proto/v1beta1/grafeas_go_proto"
+	"go.uber.org/zap"
+	rpc_status "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// decisionNoteName is the note that every decision occurrence is recorded against, policies that read decisions should match on it
+	decisionNoteName = "projects/rode/notes/enforcer-action-decision"
+	decisionAllowed  = "allowed"
+	decisionBlocked  = "blocked"
+)
+
+// enforcementDecision is the payload of the occurrence recorded for each resource, describing what the gate allowed
+type enforcementDecision struct {
+	Decision     string `json:"decision"`
+	Enforced     bool   `json:"enforced"`
+	Overridden   bool   `json:"overridden"`
+	FailedBuild  bool   `json:"failedBuild"`
+	Pass         bool   `json:"pass"`
+	PolicyGroup  string `json:"policyGroup"`
+	EvaluationId string `json:"evaluationId"`
+	Repository   string `json:"repository"`
+	Workflow     string `json:"workflow"`
+	RunId        int    `json:"runId"`
+	RunUrl       string `json:"runUrl"`
+	Actor        string `json:"actor"`
+	Environment  string `json:"environment,omitempty"`
+}
+
+func (a *EnforcerAction) newEnforcementDecision(policyGroup string, evaluation *resourceEvaluation) *enforcementDecision {
+	pass := evaluation.pass()
+	failedBuild := a.config.Enforce && evaluation.failBuild
+	// a failing resource is allowed through when fail-on-regression finds no regressions
+	overridden := a.config.Enforce && !pass && !evaluation.failBuild
+	decision := decisionAllowed
+	if failedBuild {
+		decision = decisionBlocked
+	}
+
+	return &enforcementDecision{
+		Decision:     decision,
+		Enforced:     a.config.Enforce,
+		Overridden:   overridden,
+		FailedBuild:  failedBuild,
+		Pass:         pass,
+		PolicyGroup:  policyGroup,
+		EvaluationId: evaluation.result.ResourceEvaluation.Id,
+		Repository:   a.config.GitHub.Repository,
+		Workflow:     a.config.GitHub.Workflow,
+		RunId:        a.config.GitHub.RunId,
+		RunUrl:       a.runUrl(),
+		Actor:        a.config.GitHub.Actor,
+		Environment:  a.config.Environment,
+	}
+}
+
+// recordDecisions creates a discovery occurrence for each evaluated resource, so that deployment history and later policies can see what the gate decided.
+// Decisions aren't signed, include blocked resources, and are made before anything is deployed, so they're recorded under their own
+// note and kind rather than as attestations or deployments that policies or wait-for would accept.
+func (a *EnforcerAction) recordDecisions(ctx context.Context, policyGroup string, evaluations []*resourceEvaluation) error {
+	var occurrences []*grafeas_go_proto.Occurrence
+	now := timestamppb.New(timeNow())
+	for _, evaluation := range evaluations {
+		decision := a.newEnforcementDecision(policyGroup, evaluation)
+		status, err := decisionStatus(decision)
+		if err != nil {
+			return err
+		}
+
+		occurrences = append(occurrences, &grafeas_go_proto.Occurrence{
+			Resource: &grafeas_go_proto.Resource{
+				Uri: evaluation.result.ResourceEvaluation.ResourceVersion.Version,
+			},
+			NoteName: decisionNoteName,
+			Kind:     grafeas_common_proto.NoteKind_DISCOVERY,
+			Details: &grafeas_go_proto.Occurrence_Discovered{
+				Discovered: &discovery_go_proto.Details{
+					Discovered: &discovery_go_proto.Discovered{
+						ContinuousAnalysis:  discovery_go_proto.Discovered_INACTIVE,
+						LastAnalysisTime:    now,
+						AnalysisStatus:      discovery_go_proto.Discovered_FINISHED_SUCCESS,
+						AnalysisStatusError: status,
+					},
+				},
+			},
+		})
+	}
+
+	a.logger.Info("Recording enforcement decisions", zap.Int("count", len(occurrences)))
+	if _, err := a.client.BatchCreateOccurrences(ctx, &rode.BatchCreateOccurrencesRequest{Occurrences: occurrences}); err != nil {
+		return fmt.Errorf("error recording enforcement decision: %s", err)
+	}
+
+	return nil
+}
+
+// decisionStatus describes the decision as a status, with an OK code when the resource was allowed, and the decision as its detail
+func decisionStatus(decision *enforcementDecision) (*rpc_status.Status, error) {
+	payload, err := json.Marshal(decision)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding enforcement decision: %s", err)
+	}
+
+	details := &structpb.Struct{}
+	if err = protojson.Unmarshal(payload, details); err != nil {
+		return nil, fmt.Errorf("error encoding enforcement decision: %s", err)
+	}
+
+	detail, err := anypb.New(details)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding enforcement decision: %s", err)
+	}
+
+	code := codes.OK
+	if decision.Decision == decisionBlocked {
+		code = codes.FailedPrecondition
+	}
+
+	return &rpc_status.Status{
+		Code:    int32(code),
+		Message: fmt.Sprintf("%s by policy group %s", decision.Decision, decision.PolicyGroup),
+		Details: []*anypb.Any{detail},
+	}, nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type pullRequestHead struct {
+	Sha string `json:"sha"`
+}
+
+type pullRequest struct {
+	Number int              `json:"number"`
+	Head   *pullRequestHead `json:"head"`
+}
+
+type pullRequestEvent struct {
+	PullRequest *pullRequest `json:"pull_request"`
+}
+
+type issue struct {
+	Number int `json:"number"`
+	// only present when the issue is a pull request
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+type issueComment struct {
+	Id                int64  `json:"id"`
+	Body              string `json:"body"`
+	AuthorAssociation string `json:"author_association"`
+}
+
+// commandAuthorAssociations are the relationships to the repository that allow a comment author to run an evaluate command.
+// Anyone can comment on a public repository, and their commands would otherwise replace the evaluation report.
+var commandAuthorAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+type issueCommentEvent struct {
+	Action  string        `json:"action"`
+	Issue   *issue        `json:"issue"`
+	Comment *issueComment `json:"comment"`
+}
+
+type evaluateCommand struct {
+	commentId   int64
+	policyGroup string
+}
+
+func (a *EnforcerAction) readEventPayload(event interface{}) error {
+	eventJson, err := osReadFile(a.config.GitHub.EventPath)
+	if err != nil {
+		return fmt.Errorf("error reading event payload at %s: %s", a.config.GitHub.EventPath, err)
+	}
+
+	if err := json.Unmarshal(eventJson, event); err != nil {
+		return fmt.Errorf("error unmarshalling event json: %s", err)
+	}
+
+	return nil
+}
+
+// pullRequestNumber returns the number of the pull request that triggered the workflow, or 0 if the event isn't associated with a pull request
+func (a *EnforcerAction) pullRequestNumber() (int, error) {
+	if a.config.GitHub.EventPath == "" {
+		return 0, nil
+	}
+
+	switch a.config.GitHub.EventName {
+	case githubPrEventName, githubPrTargetEventName:
+		var prEvent pullRequestEvent
+		if err := a.readEventPayload(&prEvent); err != nil {
+			return 0, err
+		}
+
+		return prEvent.PullRequest.Number, nil
+	case githubIssueCommentEventName:
+		var commentEvent issueCommentEvent
+		if err := a.readEventPayload(&commentEvent); err != nil {
+			return 0, err
+		}
+
+		if commentEvent.Issue == nil || commentEvent.Issue.PullRequest == nil {
+			return 0, nil
+		}
+
+		return commentEvent.Issue.Number, nil
+	}
+
+	return 0, nil
+}
+
+// parseEvaluateCommand looks for a line of the form "/rode evaluate [policy-group]" in a pull request comment newly created
+// by an owner, member or collaborator. A nil command is returned if the comment doesn't request an evaluation.
+func (a *EnforcerAction) parseEvaluateCommand() (*evaluateCommand, error) {
+	if a.config.GitHub.EventPath == "" {
+		return nil, nil
+	}
+
+	var commentEvent issueCommentEvent
+	if err := a.readEventPayload(&commentEvent); err != nil {
+		return nil, err
+	}
+
+	if commentEvent.Action != "created" || commentEvent.Comment == nil || commentEvent.Issue == nil || commentEvent.Issue.PullRequest == nil {
+		return nil, nil
+	}
+
+	if !contains(commandAuthorAssociations, commentEvent.Comment.AuthorAssociation) {
+		a.logger.Info("Ignoring comment from an author who isn't an owner, member or collaborator", zap.String("authorAssociation", commentEvent.Comment.AuthorAssociation))
+		return nil, nil
+	}
+
+	for _, line := range strings.Split(commentEvent.Comment.Body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.Join(fields[:2], " ") != evaluateCommandPrefix {
+			continue
+		}
+
+		command := &evaluateCommand{commentId: commentEvent.Comment.Id}
+		if len(fields) > 2 {
+			command.policyGroup = fields[2]
+		}
+
+		return command, nil
+	}
+
+	return nil, nil
+}
+
+// commitSha returns the commit that the workflow is running against. For pull requests this is the head of the pull request branch,
+// rather than GITHUB_SHA, which is the merge commit for pull_request events and the base branch for pull_request_target and issue_comment events.
+func (a *EnforcerAction) commitSha(ctx context.Context) (string, error) {
+	switch a.config.GitHub.EventName {
+	case githubPrEventName, githubPrTargetEventName:
+		if a.config.GitHub.EventPath == "" {
+			break
+		}
+
+		var prEvent pullRequestEvent
+		if err := a.readEventPayload(&prEvent); err != nil {
+			return "", err
+		}
+
+		if prEvent.PullRequest != nil && prEvent.PullRequest.Head != nil && prEvent.PullRequest.Head.Sha != "" {
+			return prEvent.PullRequest.Head.Sha, nil
+		}
+	case githubIssueCommentEventName:
+		prNumber, err := a.pullRequestNumber()
+		if err != nil {
+			return "", err
+		}
+
+		if prNumber != 0 {
+			owner, repo := a.repositorySlug()
+			pr, _, err := a.github.PullRequests.Get(ctx, owner, repo, prNumber)
+			if err != nil {
+				return "", fmt.Errorf("error fetching pull request %d: %s", prNumber, err)
+			}
+
+			return pr.GetHead().GetSHA(), nil
+		}
+	}
+
+	if a.config.GitHub.Sha == "" {
+		return "", errors.New("unable to determine the commit to evaluate, github-sha is not set")
+	}
+
+	return a.config.GitHub.Sha, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
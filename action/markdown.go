@@ -49,7 +49,7 @@ func (md *markdownPrinter) comment(message string) *markdownPrinter {
 	return md
 }
 
-func (md *markdownPrinter) header(depth int, title string, values ...interface{}) {
+func (md *markdownPrinter) header(depth int, title string, values ...interface{}) *markdownPrinter {
 	for i := 0; i < depth; i++ {
 		fmt.Fprint(&md.builder, "#")
 	}
@@ -58,26 +58,21 @@ func (md *markdownPrinter) header(depth int, title string, values ...interface{}
 	fmt.Fprintf(&md.builder, title, values...)
 	md.newline()
 	md.newline()
+
+	return md
 }
 
 func (md *markdownPrinter) table(headers []string, rows [][]string) *markdownPrinter {
-	for _, h := range headers {
-		md.tableRowEntry(h)
-	}
-
-	md.newline()
+	md.tableRow(headers)
 
+	var separators []string
 	for range headers {
-		md.tableRowEntry("--")
+		separators = append(separators, "--")
 	}
-
-	md.newline()
+	md.tableRow(separators)
 
 	for _, row := range rows {
-		for _, entry := range row {
-			md.tableRowEntry(entry)
-		}
-		md.newline()
+		md.tableRow(row)
 	}
 
 	md.newline()
@@ -85,10 +80,10 @@ func (md *markdownPrinter) table(headers []string, rows [][]string) *markdownPri
 	return md
 }
 
-func (md *markdownPrinter) tableRowEntry(entry string) {
+func (md *markdownPrinter) tableRow(entries []string) {
 	fmt.Fprint(&md.builder, "| ")
-	fmt.Fprint(&md.builder, entry)
-	fmt.Fprint(&md.builder, " |")
+	fmt.Fprint(&md.builder, strings.Join(entries, " | "))
+	fmt.Fprintln(&md.builder, " |")
 }
 
 func (md *markdownPrinter) codeBlock() *markdownPrinter {
@@ -97,6 +92,13 @@ func (md *markdownPrinter) codeBlock() *markdownPrinter {
 	return md
 }
 
+func (md *markdownPrinter) rule() *markdownPrinter {
+	fmt.Fprintln(&md.builder, "---")
+	md.newline()
+
+	return md
+}
+
 func (md *markdownPrinter) quote(message string) *markdownPrinter {
 	fmt.Fprint(&md.builder, "> ")
 	fmt.Fprintln(&md.builder, message)
@@ -0,0 +1,277 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rode/enforcer-action/config"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiBold   = "\x1b[1m"
+)
+
+// ResourceResult is the outcome of evaluating a single resource, for output formats other than the markdown report
+type ResourceResult struct {
+	ResourceUri  string          `json:"resourceUri"`
+	EvaluationId string          `json:"evaluationId"`
+	Pass         bool            `json:"pass"`
+	Policies     []*PolicyResult `json:"policies"`
+	Shortfalls   []string        `json:"shortfalls,omitempty"`
+}
+
+type PolicyResult struct {
+	Name            string   `json:"name"`
+	PolicyVersionId string   `json:"policyVersionId"`
+	Pass            bool     `json:"pass"`
+	Drift           string   `json:"drift,omitempty"`
+	Violations      []string `json:"violations,omitempty"`
+}
+
+func (a *EnforcerAction) collectResults(ctx context.Context, evaluations []*resourceEvaluation) ([]*ResourceResult, error) {
+	var results []*ResourceResult
+	for _, evaluation := range evaluations {
+		resourceEval := evaluation.result.ResourceEvaluation
+		result := &ResourceResult{
+			ResourceUri:  resourceEval.ResourceVersion.Version,
+			EvaluationId: resourceEval.Id,
+			Pass:         evaluation.pass(),
+			Policies:     []*PolicyResult{},
+			Shortfalls:   evaluation.shortfalls,
+		}
+
+		for _, policyEval := range evaluation.result.PolicyEvaluations {
+			policy, err := a.getPolicy(ctx, policyEval.PolicyVersionId)
+			if err != nil {
+				return nil, err
+			}
+
+			policyResult := &PolicyResult{
+				Name:            policy.Name,
+				PolicyVersionId: policyEval.PolicyVersionId,
+				Pass:            policyEval.Pass,
+				Drift:           a.policyVersionDrift(policy),
+			}
+			for _, v := range policyEval.Violations {
+				policyResult.Violations = append(policyResult.Violations, v.Message)
+			}
+
+			result.Policies = append(result.Policies, policyResult)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// plainWarnings strips the markdown formatting from warnings
+func (a *EnforcerAction) plainWarnings() []string {
+	var warnings []string
+	for _, warning := range a.warnings {
+		warnings = append(warnings, strings.ReplaceAll(warning, "`", ""))
+	}
+
+	return warnings
+}
+
+// WriteResult prints the result in one of the config.Output formats. Colors are only used in tables.
+func WriteResult(w io.Writer, result *ActionResult, output string, color bool) error {
+	switch output {
+	case config.OutputJson:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(result)
+	case config.OutputTable:
+		return writeTable(w, result, color)
+	default:
+		_, err := fmt.Fprintln(w, result.EvaluationReport)
+
+		return err
+	}
+}
+
+// WritePolicies prints the policies assigned to a group in one of the config.Output formats
+func WritePolicies(w io.Writer, policyGroup string, policies []*AssignedPolicy, output string) error {
+	if len(policies) == 0 && output != config.OutputJson {
+		_, err := fmt.Fprintf(w, "No policies are assigned to %s\n", policyGroup)
+
+		return err
+	}
+
+	var rows [][]string
+	for _, policy := range policies {
+		rows = append(rows, []string{policy.Name, fmt.Sprint(policy.Version), policy.PolicyVersionId})
+	}
+
+	return writeList(w, output, policies, []string{"Policy", "Version", "Policy Version ID"}, rows)
+}
+
+// WritePolicyGroups prints policy groups in one of the config.Output formats
+func WritePolicyGroups(w io.Writer, groups []*PolicyGroup, output string) error {
+	var rows [][]string
+	for _, group := range groups {
+		rows = append(rows, []string{group.Name, group.Description})
+	}
+
+	return writeList(w, output, groups, []string{"Name", "Description"}, rows)
+}
+
+// writeList prints the value as json, or the rows as a markdown or terminal table
+func writeList(w io.Writer, output string, value interface{}, headers []string, rows [][]string) error {
+	switch output {
+	case config.OutputJson:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(value)
+	case config.OutputMarkdown:
+		md := markdownPrinter{}
+		md.table(headers, rows)
+		_, err := io.WriteString(w, md.string())
+
+		return err
+	default:
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, strings.ToUpper(strings.Join(headers, "\t")))
+		for _, row := range rows {
+			fmt.Fprintln(table, strings.Join(row, "\t"))
+		}
+
+		return table.Flush()
+	}
+}
+
+func writeTable(w io.Writer, result *ActionResult, color bool) error {
+	paint := func(code, text string) string {
+		return colorize(color, code, text)
+	}
+	status := func(pass bool) string {
+		return tableStatus(pass, color)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", paint(ansiBold, "Rode Resource Evaluation"), status(result.Pass))
+	if result.DryRun {
+		b.WriteString("dry run of local policies, nothing was saved in Rode\n\n")
+	} else {
+		fmt.Fprintf(&b, "policy group: %s\n\n", result.PolicyGroup)
+	}
+
+	// the result is the last column so that color codes don't affect the alignment
+	table := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "RESOURCE\tREPORT ID\tPOLICY\tPOLICY VERSION ID\tRESULT")
+	passed, failed := 0, 0
+	for _, resource := range result.Resources {
+		if len(resource.Policies) == 0 {
+			fmt.Fprintf(table, "%s\t%s\t-\t-\t%s\n", resource.ResourceUri, resource.EvaluationId, status(resource.Pass))
+		}
+
+		for i, policy := range resource.Policies {
+			resourceUri, evaluationId := "", ""
+			if i == 0 {
+				resourceUri, evaluationId = resource.ResourceUri, resource.EvaluationId
+			}
+			policyVersionId := policy.PolicyVersionId
+			if policy.Drift != "" {
+				policyVersionId += " (drift: " + policy.Drift + ")"
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", resourceUri, evaluationId, policy.Name, policyVersionId, status(policy.Pass))
+
+			if policy.Pass {
+				passed++
+			} else {
+				failed++
+			}
+		}
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(&b, "\n%d passed, %d failed\n", passed, failed)
+
+	var violations []string
+	for _, resource := range result.Resources {
+		for _, policy := range resource.Policies {
+			if len(policy.Violations) == 0 {
+				continue
+			}
+
+			title := policy.Name
+			if len(result.Resources) > 1 {
+				title = fmt.Sprintf("%s (%s)", policy.Name, resource.ResourceUri)
+			}
+			violations = append(violations, "  "+paint(ansiBold, title))
+			for _, violation := range policy.Violations {
+				violations = append(violations, "    - "+violation)
+			}
+		}
+	}
+	if len(violations) > 0 {
+		fmt.Fprintf(&b, "\nViolations:\n%s\n", strings.Join(violations, "\n"))
+	}
+
+	var shortfalls []string
+	for _, resource := range result.Resources {
+		for _, shortfall := range resource.Shortfalls {
+			if len(result.Resources) > 1 {
+				shortfall = fmt.Sprintf("%s (%s)", shortfall, resource.ResourceUri)
+			}
+			shortfalls = append(shortfalls, "  - "+shortfall)
+		}
+	}
+	if len(shortfalls) > 0 {
+		fmt.Fprintf(&b, "\n%s\n%s\n", paint(ansiRed, "Policy requirements not met:"), strings.Join(shortfalls, "\n"))
+	}
+
+	for i, warning := range result.Warnings {
+		if i == 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s %s\n", paint(ansiYellow, "warning:"), warning)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// colorize wraps the text in an ANSI color code when colors are enabled
+func colorize(color bool, code, text string) string {
+	if !color {
+		return text
+	}
+
+	return code + text + ansiReset
+}
+
+func tableStatus(pass, color bool) string {
+	if pass {
+		return colorize(color, ansiGreen, "PASS")
+	}
+
+	return colorize(color, ansiRed, "FAIL")
+}
@@ -0,0 +1,233 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rode/enforcer-action/config"
+)
+
+var _ = Describe("output", func() {
+	Context("WriteResult", func() {
+		var (
+			result *ActionResult
+			output *bytes.Buffer
+			color  bool
+		)
+
+		BeforeEach(func() {
+			output = &bytes.Buffer{}
+			color = false
+			result = &ActionResult{
+				Pass:        false,
+				FailBuild:   true,
+				PolicyGroup: "production",
+				Resources: []*ResourceResult{
+					{
+						ResourceUri:  "harbor.example.com/app@sha256:abc",
+						EvaluationId: "evaluation-1",
+						Pass:         false,
+						Policies: []*PolicyResult{
+							{Name: "no-critical-vulns", PolicyVersionId: "policy-1.1", Pass: true},
+							{Name: "signed-images", PolicyVersionId: "policy-2.3", Pass: false, Violations: []string{"image is not signed"}},
+						},
+					},
+				},
+				Warnings:              []string{"app:latest in Dockerfile is not pinned by digest"},
+				EvaluationReport:      "# Rode Resource Evaluation Report",
+				VerificationSummaries: "{}",
+			}
+		})
+
+		It("should print the markdown report", func() {
+			Expect(WriteResult(output, result, config.OutputMarkdown, color)).To(Succeed())
+
+			Expect(output.String()).To(Equal("# Rode Resource Evaluation Report\n"))
+		})
+
+		It("should print the result as json", func() {
+			Expect(WriteResult(output, result, config.OutputJson, color)).To(Succeed())
+
+			var actual map[string]interface{}
+			Expect(json.Unmarshal(output.Bytes(), &actual)).To(Succeed())
+			Expect(actual).To(HaveKeyWithValue("pass", false))
+			Expect(actual).To(HaveKeyWithValue("failBuild", true))
+			Expect(actual).To(HaveKeyWithValue("policyGroup", "production"))
+			Expect(actual).To(HaveKey("resources"))
+			Expect(actual).To(HaveKey("warnings"))
+			Expect(actual).NotTo(HaveKey("EvaluationReport"))
+			Expect(actual).NotTo(HaveKey("VerificationSummaries"))
+			Expect(output.String()).To(ContainSubstring(`"policyVersionId": "policy-2.3"`))
+			Expect(output.String()).To(ContainSubstring(`"violations": [`))
+		})
+
+		It("should print a table of policy results", func() {
+			Expect(WriteResult(output, result, config.OutputTable, color)).To(Succeed())
+
+			Expect(output.String()).To(Equal(`Rode Resource Evaluation FAIL
+policy group: production
+
+RESOURCE                           REPORT ID     POLICY             POLICY VERSION ID  RESULT
+harbor.example.com/app@sha256:abc  evaluation-1  no-critical-vulns  policy-1.1         PASS
+                                                 signed-images      policy-2.3         FAIL
+
+1 passed, 1 failed
+
+Violations:
+  signed-images
+    - image is not signed
+
+warning: app:latest in Dockerfile is not pinned by digest
+`))
+		})
+
+		It("should list the policy requirements that weren't met", func() {
+			result.Resources[0].Shortfalls = []string{`required policy "signed-images" was not evaluated`}
+
+			Expect(WriteResult(output, result, config.OutputTable, color)).To(Succeed())
+
+			Expect(output.String()).To(ContainSubstring("\nPolicy requirements not met:\n  - required policy \"signed-images\" was not evaluated\n"))
+		})
+
+		It("should label a dry run of local policies instead of the policy group", func() {
+			result.PolicyGroup = ""
+			result.DryRun = true
+
+			Expect(WriteResult(output, result, config.OutputTable, color)).To(Succeed())
+
+			Expect(output.String()).To(HavePrefix("Rode Resource Evaluation FAIL\ndry run of local policies, nothing was saved in Rode\n\n"))
+		})
+
+		It("should mark policies whose version drifted from policy-versions", func() {
+			result.Resources[0].Policies[1].Drift = "version 2 is pinned"
+
+			Expect(WriteResult(output, result, config.OutputTable, color)).To(Succeed())
+
+			Expect(output.String()).To(ContainSubstring("signed-images      policy-2.3 (drift: version 2 is pinned)  FAIL\n"))
+		})
+
+		It("should label violations with the resource when there are several", func() {
+			result.Resources = append(result.Resources, &ResourceResult{
+				ResourceUri:  "harbor.example.com/worker@sha256:def",
+				EvaluationId: "evaluation-2",
+				Pass:         true,
+				Policies:     []*PolicyResult{},
+			})
+
+			Expect(WriteResult(output, result, config.OutputTable, color)).To(Succeed())
+
+			Expect(output.String()).To(ContainSubstring("harbor.example.com/worker@sha256:def  evaluation-2  -                  -                  PASS\n"))
+			Expect(output.String()).To(ContainSubstring("  signed-images (harbor.example.com/app@sha256:abc)\n"))
+		})
+
+		When("color is enabled", func() {
+			BeforeEach(func() {
+				color = true
+			})
+
+			It("should color the results", func() {
+				Expect(WriteResult(output, result, config.OutputTable, color)).To(Succeed())
+
+				Expect(output.String()).To(ContainSubstring("policy-1.1         " + ansiGreen + "PASS" + ansiReset + "\n"))
+				Expect(output.String()).To(ContainSubstring("policy-2.3         " + ansiRed + "FAIL" + ansiReset + "\n"))
+				Expect(output.String()).To(ContainSubstring(ansiYellow + "warning:" + ansiReset))
+			})
+
+			It("should not color json", func() {
+				Expect(WriteResult(output, result, config.OutputJson, color)).To(Succeed())
+
+				Expect(output.String()).NotTo(ContainSubstring("\x1b["))
+			})
+		})
+	})
+
+	Context("WritePolicies", func() {
+		var policies []*AssignedPolicy
+
+		BeforeEach(func() {
+			policies = []*AssignedPolicy{
+				{Name: "no-critical-vulns", PolicyId: "a", PolicyVersionId: "a.2", Version: 2},
+				{Name: "signed-images", PolicyId: "b", PolicyVersionId: "b.10", Version: 10},
+			}
+		})
+
+		It("should print a table of policies", func() {
+			output := &bytes.Buffer{}
+
+			Expect(WritePolicies(output, "production", policies, config.OutputTable)).To(Succeed())
+
+			Expect(output.String()).To(Equal(`POLICY             VERSION  POLICY VERSION ID
+no-critical-vulns  2        a.2
+signed-images      10       b.10
+`))
+		})
+
+		It("should print a markdown table of policies", func() {
+			output := &bytes.Buffer{}
+
+			Expect(WritePolicies(output, "production", policies, config.OutputMarkdown)).To(Succeed())
+
+			Expect(output.String()).To(ContainSubstring("| Policy | Version | Policy Version ID |\n"))
+			Expect(output.String()).To(ContainSubstring("| signed-images | 10 | b.10 |\n"))
+		})
+
+		It("should print the policies as json", func() {
+			output := &bytes.Buffer{}
+
+			Expect(WritePolicies(output, "production", policies, config.OutputJson)).To(Succeed())
+
+			var actual []*AssignedPolicy
+			Expect(json.Unmarshal(output.Bytes(), &actual)).To(Succeed())
+			Expect(actual).To(Equal(policies))
+		})
+
+		It("should say when no policies are assigned", func() {
+			output := &bytes.Buffer{}
+
+			Expect(WritePolicies(output, "production", []*AssignedPolicy{}, config.OutputTable)).To(Succeed())
+
+			Expect(output.String()).To(Equal("No policies are assigned to production\n"))
+		})
+
+		It("should print an empty json list when no policies are assigned", func() {
+			output := &bytes.Buffer{}
+
+			Expect(WritePolicies(output, "production", []*AssignedPolicy{}, config.OutputJson)).To(Succeed())
+
+			Expect(output.String()).To(Equal("[]\n"))
+		})
+	})
+
+	Context("WritePolicyGroups", func() {
+		It("should print a table of policy groups", func() {
+			output := &bytes.Buffer{}
+			groups := []*PolicyGroup{
+				{Name: "dev", Description: "Development"},
+				{Name: "production", Description: "Production deployments"},
+			}
+
+			Expect(WritePolicyGroups(output, groups, config.OutputTable)).To(Succeed())
+
+			Expect(output.String()).To(Equal(`NAME        DESCRIPTION
+dev         Development
+production  Production deployments
+`))
+		})
+	})
+})
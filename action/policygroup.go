@@ -0,0 +1,202 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxPolicyGroupSuggestions = 3
+
+// policyGroupInfo describes the policy group being evaluated, for the report header
+type policyGroupInfo struct {
+	name        string
+	description string
+	assignments int
+}
+
+func (p *policyGroupInfo) header() string {
+	assignments := fmt.Sprintf("%d assigned policies", p.assignments)
+	if p.assignments == 1 {
+		assignments = "1 assigned policy"
+	}
+
+	header := fmt.Sprintf("policy group: %s (%s)", p.name, assignments)
+	if p.description != "" {
+		header += " - " + p.description
+	}
+
+	return header
+}
+
+// checkPolicyGroup looks up the policy group and its assignments before evaluating, so that a typo fails with
+// suggestions instead of an error from the evaluation, and a group without policies doesn't pass unnoticed
+func (a *EnforcerAction) checkPolicyGroup(ctx context.Context, policyGroup string) error {
+	group, err := a.client.GetPolicyGroup(ctx, &rode.GetPolicyGroupRequest{Name: policyGroup})
+	if status.Code(err) == codes.NotFound {
+		return a.missingPolicyGroupError(ctx, policyGroup)
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching policy group %s: %s", policyGroup, err)
+	}
+
+	assignments, err := a.policyAssignments(ctx, policyGroup)
+	if err != nil {
+		return err
+	}
+
+	a.policyGroup = &policyGroupInfo{
+		name:        policyGroup,
+		description: group.GetDescription(),
+		assignments: len(assignments),
+	}
+
+	if len(assignments) == 0 {
+		a.logger.Warn("Policy group has no assigned policies", zap.String("policyGroup", policyGroup))
+		warning := fmt.Sprintf("policy group %s has no assigned policies", asCode(policyGroup))
+		if a.config.MinPolicies == 0 && len(a.config.RequiredPolicies) == 0 {
+			warning += ", so every resource passes"
+		}
+		a.warnings = append(a.warnings, warning)
+	}
+
+	return a.checkPolicyVersions(ctx, policyGroup, assignments)
+}
+
+func (a *EnforcerAction) missingPolicyGroupError(ctx context.Context, policyGroup string) error {
+	groups, err := a.PolicyGroups(ctx)
+	if err != nil {
+		return fmt.Errorf("policy group %q doesn't exist", policyGroup)
+	}
+
+	var names []string
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+
+	suggestions := similarNames(policyGroup, names)
+	if len(suggestions) == 0 {
+		return fmt.Errorf("policy group %q doesn't exist; run the groups list command to see the available groups", policyGroup)
+	}
+
+	for i := range suggestions {
+		suggestions[i] = fmt.Sprintf("%q", suggestions[i])
+	}
+
+	return fmt.Errorf("policy group %q doesn't exist, did you mean %s?", policyGroup, strings.Join(suggestions, " or "))
+}
+
+// policyAssignments lists every policy assignment of the policy group
+func (a *EnforcerAction) policyAssignments(ctx context.Context, policyGroup string) ([]*rode.PolicyAssignment, error) {
+	var assignments []*rode.PolicyAssignment
+	pageToken := ""
+	for {
+		response, err := a.client.ListPolicyAssignments(ctx, &rode.ListPolicyAssignmentsRequest{
+			PolicyGroup: policyGroup,
+			PageSize:    policiesPageSize,
+			PageToken:   pageToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing policy assignments for %s: %s", policyGroup, err)
+		}
+
+		assignments = append(assignments, response.GetPolicyAssignments()...)
+
+		pageToken = response.GetNextPageToken()
+		if pageToken == "" {
+			break
+		}
+	}
+
+	return assignments, nil
+}
+
+// similarNames returns the names that are close to the given name, either by edit distance or because one contains
+// the other, with the closest first
+func similarNames(name string, candidates []string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	name = strings.ToLower(name)
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	var similar []candidate
+	for _, c := range candidates {
+		lower := strings.ToLower(c)
+		distance := editDistance(name, lower)
+		if distance <= maxDistance || strings.Contains(lower, name) || strings.Contains(name, lower) {
+			similar = append(similar, candidate{c, distance})
+		}
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool {
+		return similar[i].distance < similar[j].distance
+	})
+
+	var names []string
+	for i := 0; i < len(similar) && i < maxPolicyGroupSuggestions; i++ {
+		names = append(names, similar[i].name)
+	}
+
+	return names
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	smallest := values[0]
+	for _, v := range values[1:] {
+		if v < smallest {
+			smallest = v
+		}
+	}
+
+	return smallest
+}
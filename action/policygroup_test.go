@@ -0,0 +1,57 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("policy group", func() {
+	Context("similarNames", func() {
+		candidates := []string{"production", "prod-eu", "staging", "dev", "development"}
+
+		DescribeTable("suggestions", func(name string, expected []string) {
+			Expect(similarNames(name, candidates)).To(Equal(expected))
+		},
+			Entry("transposed letters", "stagign", []string{"staging"}),
+			Entry("a typo", "prodution", []string{"production"}),
+			Entry("a prefix", "prod", []string{"prod-eu", "production"}),
+			Entry("different case", "Staging", []string{"staging"}),
+			Entry("no similar names", "qa-sandbox", nil),
+			Entry("a shorter name", "de", []string{"dev", "development"}),
+		)
+
+		It("should return at most three suggestions, closest first", func() {
+			Expect(similarNames("app", []string{"application", "app-1", "apps", "app-2"})).To(Equal([]string{"apps", "app-1", "app-2"}))
+		})
+	})
+
+	Context("editDistance", func() {
+		It("should count insertions, deletions and substitutions", func() {
+			Expect(editDistance("kitten", "sitting")).To(Equal(3))
+			Expect(editDistance("", "abc")).To(Equal(3))
+			Expect(editDistance("same", "same")).To(Equal(0))
+		})
+	})
+
+	Context("header", func() {
+		It("should describe the policy group", func() {
+			Expect((&policyGroupInfo{name: "prod", assignments: 0}).header()).To(Equal("policy group: prod (0 assigned policies)"))
+			Expect((&policyGroupInfo{name: "prod", description: "Production", assignments: 1}).header()).To(Equal("policy group: prod (1 assigned policy) - Production"))
+		})
+	})
+})
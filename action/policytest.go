@@ -0,0 +1,221 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"github.com/rode/rode/protodeps/grafeas/proto/v1beta1/grafeas_go_proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// policyDecision is the document Rode reads from a policy's package, in the shape of its OPA client
+type policyDecision struct {
+	Pass       bool                            `json:"pass"`
+	Violations []*rode.EvaluatePolicyViolation `json:"violations"`
+}
+
+// TestPolicies dry-runs local Rego files against the configured resource, so that a policy repository can see how a
+// change affects a real artifact before it's applied. Each policy is validated by Rode, then evaluated locally with the
+// resource's occurrences, which is the same input Rode evaluates saved policies with. Nothing is saved in Rode.
+func (a *EnforcerAction) TestPolicies(ctx context.Context, patterns []string) (*ActionResult, error) {
+	files, err := discoveryFindPolicyFiles(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("error finding policies: %s", err)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Rego policies found in %s", strings.Join(patterns, ", "))
+	}
+
+	resourceUri, err := a.prepareResourceUri(ctx, a.config.ResourceUri)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := a.policyInput(ctx, resourceUri)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &rode.ResourceEvaluationResult{
+		ResourceEvaluation: &rode.ResourceEvaluation{
+			Pass:            true,
+			ResourceVersion: &rode.ResourceVersion{Version: resourceUri},
+		},
+	}
+	for _, file := range files {
+		contents, err := osReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %s", file, err)
+		}
+
+		policyEvaluation, err := a.dryRunPolicy(ctx, file, string(contents), input)
+		if err != nil {
+			return nil, err
+		}
+
+		result.ResourceEvaluation.Pass = result.ResourceEvaluation.Pass && policyEvaluation.Pass
+		result.PolicyEvaluations = append(result.PolicyEvaluations, policyEvaluation)
+	}
+
+	a.dryRun = true
+	evaluation := &resourceEvaluation{
+		target: &target{uri: resourceUri},
+		result: result,
+	}
+	evaluations := []*resourceEvaluation{evaluation}
+
+	report, _, err := a.createEvaluationReport(ctx, evaluations)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := a.collectResults(ctx, evaluations)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActionResult{
+		Pass:             evaluation.pass(),
+		FailBuild:        !evaluation.pass(),
+		DryRun:           true,
+		Resources:        results,
+		Warnings:         a.plainWarnings(),
+		EvaluationReport: report,
+	}, nil
+}
+
+// policyInput lists the resource's occurrences, which Rode passes to OPA as the policy input
+func (a *EnforcerAction) policyInput(ctx context.Context, resourceUri string) (interface{}, error) {
+	var occurrences []*grafeas_go_proto.Occurrence
+	pageToken := ""
+	for {
+		response, err := a.client.ListVersionedResourceOccurrences(ctx, &rode.ListVersionedResourceOccurrencesRequest{
+			ResourceUri: resourceUri,
+			PageSize:    occurrencesPageSize,
+			PageToken:   pageToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing occurrences for %s: %s", resourceUri, err)
+		}
+
+		occurrences = append(occurrences, response.Occurrences...)
+		pageToken = response.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+
+	a.logger.Info("Found occurrences to evaluate policies with", zap.String("resourceUri", resourceUri), zap.Int("count", len(occurrences)))
+	encoded, err := protojson.Marshal(&rode.EvaluatePolicyInput{Occurrences: occurrences})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding policy input: %s", err)
+	}
+
+	var input interface{}
+	if err = json.Unmarshal(encoded, &input); err != nil {
+		return nil, fmt.Errorf("error encoding policy input: %s", err)
+	}
+
+	return input, nil
+}
+
+// dryRunPolicy validates a policy with Rode and evaluates it locally. The result is reported like a policy saved in Rode,
+// named after its package, with the file path in place of the policy version id.
+func (a *EnforcerAction) dryRunPolicy(ctx context.Context, path, policy string, input interface{}) (*rode.PolicyEvaluation, error) {
+	a.policies[path] = &rode.Policy{Name: path}
+	failed := func(messages ...string) *rode.PolicyEvaluation {
+		evaluation := &rode.PolicyEvaluation{PolicyVersionId: path}
+		for _, message := range messages {
+			evaluation.Violations = append(evaluation.Violations, &rode.EvaluatePolicyViolation{Message: message})
+		}
+
+		return evaluation
+	}
+
+	errors, err := a.validatePolicy(ctx, path, policy)
+	if err != nil {
+		return nil, err
+	}
+	if len(errors) > 0 {
+		return failed(errors...), nil
+	}
+
+	module, err := ast.ParseModule(path, policy)
+	if err != nil {
+		return failed(err.Error()), nil
+	}
+
+	query := module.Package.Path.String()
+	a.policies[path].Name = strings.TrimPrefix(query, "data.")
+
+	a.logger.Info("Evaluating policy", zap.String("path", path), zap.String("query", query))
+	resultSet, err := rego.New(rego.Query(query), rego.ParsedModule(module), rego.Input(input)).Eval(ctx)
+	if err != nil {
+		return failed(fmt.Sprintf("error evaluating policy: %s", err)), nil
+	}
+
+	// like Rode, a package without any rules is a failure
+	if len(resultSet) == 0 || len(resultSet[0].Expressions) == 0 {
+		return failed(fmt.Sprintf("%s is undefined", query)), nil
+	}
+
+	value, err := json.Marshal(resultSet[0].Expressions[0].Value)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the result of %s: %s", path, err)
+	}
+
+	decision := &policyDecision{}
+	if err = json.Unmarshal(value, decision); err != nil {
+		return failed(fmt.Sprintf("the policy result isn't a pass and violations document: %s", err)), nil
+	}
+
+	return &rode.PolicyEvaluation{
+		PolicyVersionId: path,
+		Pass:            decision.Pass,
+		Violations:      decision.Violations,
+	}, nil
+}
+
+// validatePolicy compiles the policy with Rode, returning its compile errors
+func (a *EnforcerAction) validatePolicy(ctx context.Context, path, policy string) ([]string, error) {
+	a.logger.Info("Validating policy", zap.String("path", path))
+	response, err := a.client.ValidatePolicy(ctx, &rode.ValidatePolicyRequest{Policy: policy})
+
+	// Rode may reject a policy that doesn't compile as an invalid argument, which fails the policy rather than the command
+	if status.Code(err) == codes.InvalidArgument {
+		return []string{status.Convert(err).Message()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error validating %s: %s", path, err)
+	}
+
+	errors := response.GetErrors()
+	if !response.GetCompile() && len(errors) == 0 {
+		errors = []string{"the policy doesn't compile"}
+	}
+
+	return errors, nil
+}
@@ -0,0 +1,265 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rode/enforcer-action/config"
+	"github.com/rode/enforcer-action/discovery"
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"github.com/rode/rode/proto/v1alpha1fakes"
+	grafeas_common_proto "github.com/rode/rode/protodeps/grafeas/proto/v1beta1/common_go_proto"
+	"github.com/rode/rode/protodeps/grafeas/proto/v1beta1/grafeas_go_proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	signedPolicy = `package signed
+
+pass {
+	count(violations) == 0
+}
+
+violations[result] {
+	not signed
+	result := {"pass": false, "message": "the image isn't signed"}
+}
+
+signed {
+	input.occurrences[_].kind == "ATTESTATION"
+}`
+	buildPolicy = `package rode.build
+
+default pass = false
+
+pass {
+	input.occurrences[_].kind == "BUILD"
+}`
+)
+
+var _ = Describe("policy tests", func() {
+	var (
+		ctx         = context.Background()
+		rodeClient  *v1alpha1fakes.FakeRodeClient
+		action      *EnforcerAction
+		conf        *config.Config
+		policies    map[string]string
+		occurrences []*grafeas_go_proto.Occurrence
+		resourceUri string
+	)
+
+	BeforeEach(func() {
+		resourceUri = fakeImageDigestUri()
+		policies = map[string]string{
+			"policies/signed.rego": signedPolicy,
+			"policies/build.rego":  buildPolicy,
+		}
+		occurrences = []*grafeas_go_proto.Occurrence{
+			{Resource: &grafeas_go_proto.Resource{Uri: resourceUri}, Kind: grafeas_common_proto.NoteKind_ATTESTATION},
+			{Resource: &grafeas_go_proto.Resource{Uri: resourceUri}, Kind: grafeas_common_proto.NoteKind_BUILD},
+		}
+
+		discoveryFindPolicyFiles = func(patterns []string) ([]string, error) {
+			Expect(patterns).To(Equal([]string{"policies/"}))
+
+			return []string{"policies/signed.rego", "policies/build.rego"}, nil
+		}
+		osReadFile = func(name string) ([]byte, error) {
+			return []byte(policies[name]), nil
+		}
+
+		rodeClient = &v1alpha1fakes.FakeRodeClient{}
+		rodeClient.ValidatePolicyStub = func(_ context.Context, request *rode.ValidatePolicyRequest, _ ...grpc.CallOption) (*rode.ValidatePolicyResponse, error) {
+			return &rode.ValidatePolicyResponse{Policy: request.Policy, Compile: true}, nil
+		}
+		rodeClient.ListVersionedResourceOccurrencesStub = func(_ context.Context, _ *rode.ListVersionedResourceOccurrencesRequest, _ ...grpc.CallOption) (*rode.ListVersionedResourceOccurrencesResponse, error) {
+			return &rode.ListVersionedResourceOccurrencesResponse{Occurrences: occurrences}, nil
+		}
+		conf = &config.Config{ResourceUri: resourceUri, Registry: &config.RegistryConfig{}}
+		action = NewEnforcerAction(logger, conf, rodeClient, nil, nil)
+	})
+
+	AfterEach(func() {
+		discoveryFindPolicyFiles = discovery.FindPolicyFiles
+		osReadFile = os.ReadFile
+	})
+
+	Context("TestPolicies", func() {
+		It("should evaluate each policy against the resource's occurrences", func() {
+			result, err := action.TestPolicies(ctx, []string{"policies/"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Pass).To(BeTrue())
+			Expect(result.FailBuild).To(BeFalse())
+			Expect(result.DryRun).To(BeTrue())
+			Expect(result.Resources).To(Equal([]*ResourceResult{
+				{
+					ResourceUri: resourceUri,
+					Pass:        true,
+					Policies: []*PolicyResult{
+						{Name: "signed", PolicyVersionId: "policies/signed.rego", Pass: true},
+						{Name: "rode.build", PolicyVersionId: "policies/build.rego", Pass: true},
+					},
+				},
+			}))
+
+			Expect(rodeClient.ListVersionedResourceOccurrencesCallCount()).To(Equal(1))
+			_, occurrencesRequest, _ := rodeClient.ListVersionedResourceOccurrencesArgsForCall(0)
+			Expect(occurrencesRequest.ResourceUri).To(Equal(resourceUri))
+		})
+
+		It("should validate each policy with Rode without saving anything", func() {
+			_, err := action.TestPolicies(ctx, []string{"policies/"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rodeClient.ValidatePolicyCallCount()).To(Equal(2))
+			_, request, _ := rodeClient.ValidatePolicyArgsForCall(1)
+			Expect(request.Policy).To(Equal(buildPolicy))
+
+			Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(0))
+			Expect(rodeClient.BatchCreateOccurrencesCallCount()).To(Equal(0))
+			Expect(rodeClient.GetPolicyCallCount()).To(Equal(0))
+		})
+
+		It("should render the evaluation report", func() {
+			result, err := action.TestPolicies(ctx, []string{"policies/"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.EvaluationReport).To(HavePrefix("# Rode Resource Evaluation Report ✅ (PASSED)\n\n> dry run of local policies, nothing was saved in Rode\n"))
+			Expect(result.EvaluationReport).NotTo(ContainSubstring("report id"))
+			Expect(result.EvaluationReport).To(ContainSubstring("### rode.build ✅ (PASSED)\n\nPolicy version: `policies/build.rego`"))
+		})
+
+		When("the resource doesn't meet a policy", func() {
+			BeforeEach(func() {
+				occurrences = occurrences[1:]
+			})
+
+			It("should report the violations and fail", func() {
+				result, err := action.TestPolicies(ctx, []string{"policies/"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Pass).To(BeFalse())
+				Expect(result.FailBuild).To(BeTrue())
+				Expect(result.Resources[0].Policies[0]).To(Equal(&PolicyResult{
+					Name:            "signed",
+					PolicyVersionId: "policies/signed.rego",
+					Violations:      []string{"the image isn't signed"},
+				}))
+				Expect(result.Resources[0].Policies[1].Pass).To(BeTrue())
+				Expect(result.EvaluationReport).To(ContainSubstring("### signed ❌ (FAILED)"))
+			})
+		})
+
+		When("a policy package doesn't set pass", func() {
+			BeforeEach(func() {
+				policies["policies/build.rego"] = "package rode.build\n\nallow = true"
+			})
+
+			It("should fail the policy", func() {
+				result, err := action.TestPolicies(ctx, []string{"policies/"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Pass).To(BeFalse())
+				Expect(result.Resources[0].Policies[1].Pass).To(BeFalse())
+			})
+		})
+
+		When("Rode finds validation errors", func() {
+			BeforeEach(func() {
+				rodeClient.ValidatePolicyStub = func(_ context.Context, request *rode.ValidatePolicyRequest, _ ...grpc.CallOption) (*rode.ValidatePolicyResponse, error) {
+					if request.Policy == buildPolicy {
+						return &rode.ValidatePolicyResponse{Compile: false, Errors: []string{"rego_parse_error: unexpected eof"}}, nil
+					}
+
+					return &rode.ValidatePolicyResponse{Compile: true}, nil
+				}
+			})
+
+			It("should fail the policy with the errors, named by its path", func() {
+				result, err := action.TestPolicies(ctx, []string{"policies/"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Pass).To(BeFalse())
+				Expect(result.Resources[0].Policies[0].Pass).To(BeTrue())
+				Expect(result.Resources[0].Policies[1]).To(Equal(&PolicyResult{
+					Name:            "policies/build.rego",
+					PolicyVersionId: "policies/build.rego",
+					Violations:      []string{"rego_parse_error: unexpected eof"},
+				}))
+			})
+		})
+
+		When("Rode rejects a policy as invalid", func() {
+			BeforeEach(func() {
+				rodeClient.ValidatePolicyStub = nil
+				rodeClient.ValidatePolicyReturns(nil, status.Error(codes.InvalidArgument, "failed to compile the provided policy"))
+			})
+
+			It("should fail the policy", func() {
+				result, err := action.TestPolicies(ctx, []string{"policies/"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Pass).To(BeFalse())
+				Expect(result.Resources[0].Policies[0].Violations).To(ConsistOf("failed to compile the provided policy"))
+			})
+		})
+
+		It("should return an error when Rode can't validate a policy", func() {
+			rodeClient.ValidatePolicyStub = nil
+			rodeClient.ValidatePolicyReturns(nil, status.Error(codes.Unavailable, "connection refused"))
+
+			_, err := action.TestPolicies(ctx, []string{"policies/"})
+
+			Expect(err).To(MatchError(ContainSubstring("error validating policies/signed.rego")))
+		})
+
+		It("should return an error when the occurrences can't be listed", func() {
+			rodeClient.ListVersionedResourceOccurrencesStub = nil
+			rodeClient.ListVersionedResourceOccurrencesReturns(nil, errors.New("connection refused"))
+
+			_, err := action.TestPolicies(ctx, []string{"policies/"})
+
+			Expect(err).To(MatchError(ContainSubstring("error listing occurrences for " + resourceUri)))
+		})
+
+		It("should return an error when no policies are found", func() {
+			discoveryFindPolicyFiles = func(_ []string) ([]string, error) {
+				return nil, nil
+			}
+
+			_, err := action.TestPolicies(ctx, []string{"policies/"})
+
+			Expect(err).To(MatchError("no Rego policies found in policies/"))
+		})
+
+		It("should return an error when a policy can't be read", func() {
+			osReadFile = func(_ string) ([]byte, error) {
+				return nil, errors.New("permission denied")
+			}
+
+			_, err := action.TestPolicies(ctx, []string{"policies/"})
+
+			Expect(err).To(MatchError("error reading policies/signed.rego: permission denied"))
+		})
+	})
+})
@@ -0,0 +1,99 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rode/enforcer-action/config"
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"go.uber.org/zap"
+)
+
+// checkPolicyVersions compares the versions of the assigned policies to policy-versions before evaluating, so that a
+// policy changed between a release candidate and its promotion doesn't silently change the gate. Drift fails every
+// resource, or is reported as a warning when policy-version-drift is warn.
+func (a *EnforcerAction) checkPolicyVersions(ctx context.Context, policyGroup string, assignments []*rode.PolicyAssignment) error {
+	if len(a.config.PolicyVersions) == 0 {
+		return nil
+	}
+
+	assigned := map[string]*rode.Policy{}
+	for _, assignment := range assignments {
+		policy, err := a.getPolicy(ctx, assignment.GetPolicyVersionId())
+		if err != nil {
+			return fmt.Errorf("error fetching assigned policy %s: %s", assignment.GetPolicyVersionId(), err)
+		}
+
+		assigned[policy.Name] = policy
+		assigned[policy.Id] = policy
+	}
+
+	var pinned []string
+	for name := range a.config.PolicyVersions {
+		pinned = append(pinned, name)
+	}
+	sort.Strings(pinned)
+
+	var drift []string
+	for _, name := range pinned {
+		version := a.config.PolicyVersions[name]
+		policy, ok := assigned[name]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("policy %q is pinned to version %d, but isn't assigned to %s", name, version, policyGroup))
+			continue
+		}
+
+		if actual := policy.GetPolicy().GetVersion(); actual != version {
+			drift = append(drift, fmt.Sprintf("policy %q is assigned at version %d, but version %d is pinned", name, actual, version))
+		}
+	}
+
+	if len(drift) == 0 {
+		return nil
+	}
+
+	a.logger.Warn("Assigned policy versions don't match policy-versions", zap.String("policyGroup", policyGroup), zap.Strings("drift", drift))
+	if a.config.PolicyVersionDrift == config.PolicyVersionDriftWarn {
+		a.warnings = append(a.warnings, drift...)
+	} else {
+		a.policyDrift = drift
+	}
+
+	return nil
+}
+
+// pinnedVersion is the version of the policy in policy-versions, which may name the policy by name or id
+func (a *EnforcerAction) pinnedVersion(policy *rode.Policy) (uint32, bool) {
+	if version, ok := a.config.PolicyVersions[policy.Name]; ok {
+		return version, true
+	}
+
+	version, ok := a.config.PolicyVersions[policy.Id]
+
+	return version, ok
+}
+
+// policyVersionDrift describes how an evaluated policy differs from its pinned version, or is empty if it doesn't
+func (a *EnforcerAction) policyVersionDrift(policy *rode.Policy) string {
+	version, ok := a.pinnedVersion(policy)
+	if !ok || policy.GetPolicy().GetVersion() == version {
+		return ""
+	}
+
+	return fmt.Sprintf("version %d is pinned", version)
+}
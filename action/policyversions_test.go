@@ -0,0 +1,116 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rode/enforcer-action/config"
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"github.com/rode/rode/proto/v1alpha1fakes"
+	"google.golang.org/grpc"
+)
+
+var _ = Describe("policy versions", func() {
+	var (
+		ctx         = context.Background()
+		conf        *config.Config
+		rodeClient  *v1alpha1fakes.FakeRodeClient
+		action      *EnforcerAction
+		assignments []*rode.PolicyAssignment
+		policies    map[string]*rode.Policy
+	)
+
+	BeforeEach(func() {
+		policies = map[string]*rode.Policy{
+			"a.3": {Id: "a", Name: "signed-images", Policy: &rode.PolicyEntity{Version: 3}},
+			"b.7": {Id: "b", Name: "no-critical-vulns", Policy: &rode.PolicyEntity{Version: 7}},
+		}
+		rodeClient = &v1alpha1fakes.FakeRodeClient{}
+		rodeClient.GetPolicyStub = func(_ context.Context, request *rode.GetPolicyRequest, _ ...grpc.CallOption) (*rode.Policy, error) {
+			return policies[request.Id], nil
+		}
+
+		conf = &config.Config{
+			PolicyVersionDrift: config.PolicyVersionDriftFail,
+		}
+		action = NewEnforcerAction(logger, conf, rodeClient, nil, nil)
+		assignments = []*rode.PolicyAssignment{{PolicyVersionId: "a.3"}, {PolicyVersionId: "b.7"}}
+	})
+
+	Context("checkPolicyVersions", func() {
+		It("should not fetch the assigned policies when no versions are pinned", func() {
+			Expect(action.checkPolicyVersions(ctx, "prod", assignments)).To(Succeed())
+
+			Expect(rodeClient.GetPolicyCallCount()).To(Equal(0))
+		})
+
+		It("should accept assignments that match the pinned versions by name or id", func() {
+			conf.PolicyVersions = map[string]uint32{"signed-images": 3, "b": 7}
+
+			Expect(action.checkPolicyVersions(ctx, "prod", assignments)).To(Succeed())
+
+			Expect(action.policyDrift).To(BeEmpty())
+			Expect(action.warnings).To(BeEmpty())
+		})
+
+		It("should fail the evaluation when an assignment drifted from its pinned version", func() {
+			conf.PolicyVersions = map[string]uint32{"signed-images": 2, "unassigned": 1}
+
+			Expect(action.checkPolicyVersions(ctx, "prod", assignments)).To(Succeed())
+
+			Expect(action.policyDrift).To(Equal([]string{
+				`policy "signed-images" is assigned at version 3, but version 2 is pinned`,
+				`policy "unassigned" is pinned to version 1, but isn't assigned to prod`,
+			}))
+			Expect(action.warnings).To(BeEmpty())
+			Expect(action.policyShortfalls(ctx, &rode.ResourceEvaluationResult{})).To(Equal(action.policyDrift))
+		})
+
+		It("should only warn about drift when policy-version-drift is warn", func() {
+			conf.PolicyVersions = map[string]uint32{"signed-images": 2}
+			conf.PolicyVersionDrift = config.PolicyVersionDriftWarn
+
+			Expect(action.checkPolicyVersions(ctx, "prod", assignments)).To(Succeed())
+
+			Expect(action.policyDrift).To(BeEmpty())
+			Expect(action.warnings).To(ConsistOf(`policy "signed-images" is assigned at version 3, but version 2 is pinned`))
+		})
+
+		It("should return an error when an assigned policy can't be fetched", func() {
+			conf.PolicyVersions = map[string]uint32{"signed-images": 3}
+			rodeClient.GetPolicyStub = nil
+			rodeClient.GetPolicyReturns(nil, errors.New("not found"))
+
+			Expect(action.checkPolicyVersions(ctx, "prod", assignments)).To(MatchError("error fetching assigned policy a.3: not found"))
+		})
+	})
+
+	Context("policyVersionDrift", func() {
+		It("should be empty for policies that aren't pinned", func() {
+			Expect(action.policyVersionDrift(policies["a.3"])).To(BeEmpty())
+		})
+
+		It("should describe the pinned version when it doesn't match", func() {
+			conf.PolicyVersions = map[string]uint32{"b": 6}
+
+			Expect(action.policyVersionDrift(policies["a.3"])).To(BeEmpty())
+			Expect(action.policyVersionDrift(policies["b.7"])).To(Equal("version 6 is pinned"))
+		})
+	})
+})
@@ -0,0 +1,213 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rode/enforcer-action/intoto"
+	"github.com/rode/enforcer-action/resource"
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"github.com/rode/rode/protodeps/grafeas/proto/v1beta1/build_go_proto"
+	grafeas_common_proto "github.com/rode/rode/protodeps/grafeas/proto/v1beta1/common_go_proto"
+	"github.com/rode/rode/protodeps/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/rode/rode/protodeps/grafeas/proto/v1beta1/provenance_go_proto"
+	"github.com/rode/rode/protodeps/grafeas/proto/v1beta1/source_go_proto"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	buildNoteName        = "projects/rode/notes/enforcer-action-build"
+	githubActionsBuilder = "GitHub Actions"
+)
+
+// uploadProvenance creates a build occurrence for a resource built by the workflow, so that it's evaluated with provenance
+func (a *EnforcerAction) uploadProvenance(ctx context.Context, resourceUri string) error {
+	provenance, provenanceBytes, err := a.buildProvenance(ctx, resourceUri)
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("Uploading build provenance", zap.String("resourceUri", resourceUri), zap.String("buildId", provenance.Id))
+	_, err = a.client.BatchCreateOccurrences(ctx, &rode.BatchCreateOccurrencesRequest{
+		Occurrences: []*grafeas_go_proto.Occurrence{
+			{
+				Resource: &grafeas_go_proto.Resource{
+					Uri: resourceUri,
+				},
+				NoteName: buildNoteName,
+				Kind:     grafeas_common_proto.NoteKind_BUILD,
+				Details: &grafeas_go_proto.Occurrence_Build{
+					Build: &build_go_proto.Details{
+						Provenance:      provenance,
+						ProvenanceBytes: provenanceBytes,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error uploading build provenance: %s", err)
+	}
+
+	return nil
+}
+
+// buildProvenance describes the build from the GitHub Actions context, overridden by the provenance file if one is configured
+func (a *EnforcerAction) buildProvenance(ctx context.Context, resourceUri string) (*provenance_go_proto.BuildProvenance, string, error) {
+	now := timestamppb.New(timeNow())
+	digest := resourceDigest(resourceUri)
+	provenance := &provenance_go_proto.BuildProvenance{
+		Id:             strconv.Itoa(a.config.GitHub.RunId),
+		CreateTime:     now,
+		EndTime:        now,
+		Creator:        a.config.GitHub.Actor,
+		LogsUri:        a.runUrl(),
+		BuilderVersion: githubActionsBuilder,
+		BuildOptions: map[string]string{
+			"repository": a.config.GitHub.Repository,
+			"workflow":   a.config.GitHub.Workflow,
+			"runId":      strconv.Itoa(a.config.GitHub.RunId),
+		},
+		BuiltArtifacts: []*provenance_go_proto.Artifact{
+			{
+				Id:       resourceUri,
+				Checksum: digest,
+				Names:    []string{resourceUri},
+			},
+		},
+		SourceProvenance: gitSource(fmt.Sprintf("%s/%s", a.config.GitHub.ServerUrl, a.config.GitHub.Repository), a.config.GitHub.Sha),
+	}
+
+	if startTime := a.workflowRunStartTime(ctx); startTime != nil {
+		provenance.StartTime = startTime
+	}
+
+	if a.config.ProvenanceFile == "" {
+		return provenance, "", nil
+	}
+
+	contents, err := osReadFile(a.config.ProvenanceFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading provenance file: %s", err)
+	}
+
+	statement, err := intoto.ParseStatement(contents)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if digest != "" && !statement.HasSubjectDigest(digest) {
+		return nil, "", fmt.Errorf("provenance file %s doesn't have a subject with the digest %s", a.config.ProvenanceFile, digest)
+	}
+
+	// provenance in other formats is still attached as-is
+	if slsa, err := statement.SlsaProvenance(); err == nil {
+		applySlsaProvenance(provenance, statement, slsa)
+	}
+
+	return provenance, string(contents), nil
+}
+
+func applySlsaProvenance(provenance *provenance_go_proto.BuildProvenance, statement *intoto.Statement, slsa *intoto.SlsaProvenance) {
+	if slsa.Builder.Id != "" {
+		provenance.BuilderVersion = slsa.Builder.Id
+	}
+
+	if slsa.Metadata.BuildInvocationId != "" {
+		provenance.Id = slsa.Metadata.BuildInvocationId
+	}
+
+	if slsa.Metadata.BuildStartedOn != nil {
+		provenance.StartTime = timestamppb.New(*slsa.Metadata.BuildStartedOn)
+	}
+
+	if slsa.Metadata.BuildFinishedOn != nil {
+		provenance.EndTime = timestamppb.New(*slsa.Metadata.BuildFinishedOn)
+	}
+
+	var artifacts []*provenance_go_proto.Artifact
+	for _, subject := range statement.Subject {
+		checksum, _ := subject.Sha256Digest()
+		artifacts = append(artifacts, &provenance_go_proto.Artifact{
+			Id:       subject.Name,
+			Checksum: checksum,
+			Names:    []string{subject.Name},
+		})
+	}
+	provenance.BuiltArtifacts = artifacts
+
+	for _, material := range slsa.Materials {
+		if strings.HasPrefix(material.Uri, "git+") && material.Digest["sha1"] != "" {
+			url := strings.SplitN(strings.TrimPrefix(material.Uri, "git+"), "@", 2)[0]
+			provenance.SourceProvenance = gitSource(url, material.Digest["sha1"])
+			break
+		}
+	}
+}
+
+// workflowRunStartTime looks up when the run started, which isn't available in the environment. It's omitted if the lookup fails.
+func (a *EnforcerAction) workflowRunStartTime(ctx context.Context) *timestamppb.Timestamp {
+	if !a.config.GitHub.Actions || a.config.GitHub.RunId == 0 {
+		return nil
+	}
+
+	owner, repo := a.repositorySlug()
+	run, _, err := a.github.Actions.GetWorkflowRunByID(ctx, owner, repo, int64(a.config.GitHub.RunId))
+	if err != nil {
+		a.logger.Warn("Unable to fetch workflow run start time", zap.Error(err))
+		return nil
+	}
+
+	if run.CreatedAt == nil {
+		return nil
+	}
+
+	return timestamppb.New(run.CreatedAt.Time)
+}
+
+func gitSource(url, revision string) *provenance_go_proto.Source {
+	return &provenance_go_proto.Source{
+		Context: &source_go_proto.SourceContext{
+			Context: &source_go_proto.SourceContext_Git{
+				Git: &source_go_proto.GitSourceContext{
+					Url:        url,
+					RevisionId: revision,
+				},
+			},
+		},
+	}
+}
+
+// resourceDigest returns the sha256 digest of images and files, which is used to match provenance subjects
+func resourceDigest(resourceUri string) string {
+	uri, err := resource.Parse(resourceUri)
+	if err != nil {
+		return ""
+	}
+
+	switch uri.Type {
+	case rode.ResourceType_DOCKER:
+		return uri.Version
+	case rode.ResourceType_FILE:
+		return "sha256:" + uri.Version
+	}
+
+	return ""
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	rode "github.com/rode/rode/proto/v1alpha1"
+)
+
+// policyShortfalls explains how the evaluation falls short of min-policies, required-policies and policy-versions. A
+// policy group whose assignments were removed by accident would otherwise pass every resource.
+func (a *EnforcerAction) policyShortfalls(ctx context.Context, result *rode.ResourceEvaluationResult) ([]string, error) {
+	shortfalls := append([]string{}, a.policyDrift...)
+	evaluated := len(result.PolicyEvaluations)
+	if evaluated < a.config.MinPolicies {
+		shortfalls = append(shortfalls, fmt.Sprintf("%d %s evaluated, but at least %d %s required", evaluated, pluralize(evaluated, "policy was", "policies were"), a.config.MinPolicies, pluralize(a.config.MinPolicies, "is", "are")))
+	}
+
+	if len(a.config.RequiredPolicies) == 0 {
+		return shortfalls, nil
+	}
+
+	found := map[string]bool{}
+	for _, policyEval := range result.PolicyEvaluations {
+		policy, err := a.getPolicy(ctx, policyEval.PolicyVersionId)
+		if err != nil {
+			return nil, err
+		}
+
+		found[policy.Name] = true
+		found[policy.Id] = true
+	}
+
+	for _, required := range a.config.RequiredPolicies {
+		if !found[required] {
+			shortfalls = append(shortfalls, fmt.Sprintf("required policy %q was not evaluated", required))
+		}
+	}
+
+	return shortfalls, nil
+}
+
+func (a *EnforcerAction) renderPolicyShortfalls(md *markdownPrinter, shortfalls []string, depth int) {
+	if len(shortfalls) == 0 {
+		return
+	}
+
+	md.header(depth, "❌ Policy Requirements").list(shortfalls).newline()
+}
+
+func pluralize(count int, singular, plural string) string {
+	if count == 1 {
+		return singular
+	}
+
+	return plural
+}
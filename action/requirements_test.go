@@ -0,0 +1,72 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rode/enforcer-action/config"
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"github.com/rode/rode/proto/v1alpha1fakes"
+	"google.golang.org/grpc"
+)
+
+var _ = Describe("policy requirements", func() {
+	var (
+		ctx    = context.Background()
+		conf   *config.Config
+		action *EnforcerAction
+		result *rode.ResourceEvaluationResult
+	)
+
+	BeforeEach(func() {
+		rodeClient := &v1alpha1fakes.FakeRodeClient{}
+		rodeClient.GetPolicyStub = func(_ context.Context, request *rode.GetPolicyRequest, _ ...grpc.CallOption) (*rode.Policy, error) {
+			return &rode.Policy{Id: "a", Name: "signed-images"}, nil
+		}
+
+		conf = &config.Config{}
+		action = NewEnforcerAction(logger, conf, rodeClient, nil, nil)
+		result = &rode.ResourceEvaluationResult{
+			PolicyEvaluations: []*rode.PolicyEvaluation{{PolicyVersionId: "a.1", Pass: true}},
+		}
+	})
+
+	Context("policyShortfalls", func() {
+		It("should be empty when the requirements are met", func() {
+			conf.MinPolicies = 1
+			conf.RequiredPolicies = []string{"signed-images", "a"}
+
+			Expect(action.policyShortfalls(ctx, result)).To(BeEmpty())
+		})
+
+		It("should explain a shortfall in the number of policies", func() {
+			conf.MinPolicies = 2
+
+			Expect(action.policyShortfalls(ctx, result)).To(ConsistOf("1 policy was evaluated, but at least 2 are required"))
+		})
+
+		It("should list each required policy that wasn't evaluated", func() {
+			conf.RequiredPolicies = []string{"signed-images", "no-critical-vulns", "b"}
+
+			Expect(action.policyShortfalls(ctx, result)).To(Equal([]string{
+				`required policy "no-critical-vulns" was not evaluated`,
+				`required policy "b" was not evaluated`,
+			}))
+		})
+	})
+})
@@ -0,0 +1,51 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// resolveResourceUri replaces an image tag with its digest when digest resolution is enabled.
+// Resource URIs with a scheme (e.g., git:// or npm://) aren't images, so they're left alone.
+func (a *EnforcerAction) resolveResourceUri(ctx context.Context, resourceUri string) (string, error) {
+	if !a.config.Registry.ResolveDigest || strings.Contains(resourceUri, "://") {
+		return resourceUri, nil
+	}
+
+	resolved, err := a.resolver.ResolveDigest(ctx, resourceUri)
+	if err != nil {
+		return "", err
+	}
+
+	if resolved != resourceUri {
+		a.logger.Info("Resolved image digest", zap.String("image", resourceUri), zap.String("resourceUri", resolved))
+		a.resolvedTags[resolved] = resourceUri
+	}
+
+	return resolved, nil
+}
+
+// resourceMetadata returns the headers and values for the resource metadata table in the report
+func (a *EnforcerAction) resourceMetadata(resourceUri string) ([]string, []string) {
+	if tag, ok := a.resolvedTags[resourceUri]; ok {
+		return []string{"Resource URI", "Resolved From"}, []string{asCode(resourceUri), asCode(tag)}
+	}
+
+	return []string{"Resource URI"}, []string{asCode(resourceUri)}
+}
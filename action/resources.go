@@ -0,0 +1,128 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rode/enforcer-action/discovery"
+	"github.com/rode/enforcer-action/resource"
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"go.uber.org/zap"
+)
+
+// target is a resource URI to evaluate, along with where it was found and whether the workflow built it
+type target struct {
+	uri    string
+	source string
+	built  bool
+}
+
+// label identifies the resource across runs; digests change with every build, so they're omitted
+func (r *target) label() string {
+	return strings.SplitN(r.uri, "@", 2)[0]
+}
+
+type resourceEvaluation struct {
+	target     *target
+	result     *rode.ResourceEvaluationResult
+	comparison *baselineComparison
+	shortfalls []string
+	failBuild  bool
+}
+
+// pass is the result from Rode, unless the evaluation didn't meet the policy requirements
+func (e *resourceEvaluation) pass() bool {
+	return e.result.ResourceEvaluation.Pass && len(e.shortfalls) == 0
+}
+
+// collectResources gathers the configured resource URI, the workflow commit, and any images discovered in manifests or build metadata, skipping duplicates
+func (a *EnforcerAction) collectResources(ctx context.Context) ([]*target, error) {
+	var resources []*target
+	seen := map[string]bool{}
+	addResource := func(r *target) {
+		if !seen[r.uri] {
+			seen[r.uri] = true
+			resources = append(resources, r)
+		}
+	}
+
+	if a.config.ResourceUri != "" {
+		addResource(&target{uri: a.config.ResourceUri, built: a.config.ResourceUriBuilt})
+	}
+
+	if a.config.EvaluateCommit {
+		sha, err := a.commitSha(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		uri, err := resource.GitUri(a.config.GitHub.ServerUrl, a.config.GitHub.Repository, sha)
+		if err != nil {
+			return nil, fmt.Errorf("error building git resource uri: %s", err)
+		}
+
+		a.logger.Info("Evaluating workflow commit", zap.String("sha", sha))
+		addResource(&target{uri: uri})
+	}
+
+	if len(a.config.Manifests) > 0 {
+		images, err := discoveryFindImages(a.config.Manifests)
+		if err != nil {
+			return nil, fmt.Errorf("error discovering images in manifests: %s", err)
+		}
+
+		a.logger.Info("Discovered images in manifests", zap.Int("count", len(images)))
+		a.addImages(images, false, addResource)
+	}
+
+	if a.config.BuildMetadata != "" {
+		images, err := discoveryFindBuildMetadataImages(a.config.BuildMetadata)
+		if err != nil {
+			return nil, err
+		}
+
+		a.logger.Info("Found images in build metadata", zap.Int("count", len(images)))
+		a.addImages(images, true, addResource)
+	}
+
+	if len(resources) == 0 {
+		return nil, errors.New("no resources found to evaluate")
+	}
+
+	return resources, nil
+}
+
+func (a *EnforcerAction) addImages(images []*discovery.Image, built bool, addResource func(*target)) {
+	for _, image := range images {
+		if !image.Pinned() {
+			a.logger.Warn("Image is not pinned by digest", zap.String("image", image.Reference), zap.String("source", image.Source))
+			a.warnings = append(a.warnings, fmt.Sprintf("%s in %s is not pinned by digest", asCode(image.Reference), asCode(image.Source)))
+		}
+
+		addResource(&target{uri: image.Reference, source: image.Source, built: built})
+	}
+}
+
+func (a *EnforcerAction) renderWarnings(md *markdownPrinter, depth int) {
+	if len(a.warnings) == 0 {
+		return
+	}
+
+	md.header(depth, "⚠️ Warnings").list(a.warnings).newline()
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+const evaluationSummaryCommentPrefix = "enforcer-action-summary: "
+
+var evaluationSummaryPattern = regexp.MustCompile("<!---" + evaluationSummaryCommentPrefix + "([A-Za-z0-9+/=]+)--->")
+
+// evaluationSummary is a machine-readable record of policy results that's embedded in the pull request comment,
+// so that the next run can describe what changed.
+type evaluationSummary struct {
+	Policies map[string]bool `json:"policies"`
+}
+
+func newEvaluationSummary() *evaluationSummary {
+	return &evaluationSummary{
+		Policies: map[string]bool{},
+	}
+}
+
+// encode returns the summary as base64-encoded JSON, which is safe to include in an HTML comment
+func (s *evaluationSummary) encode() (string, error) {
+	summaryJson, err := json.Marshal(s)
+	if err != nil {
+		return "", fmt.Errorf("error encoding evaluation summary: %s", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(summaryJson), nil
+}
+
+// parseEvaluationSummary extracts the summary from a previous report. A nil summary is returned if the report predates summaries.
+func parseEvaluationSummary(report string) (*evaluationSummary, error) {
+	matches := evaluationSummaryPattern.FindStringSubmatch(report)
+	if matches == nil {
+		return nil, nil
+	}
+
+	summaryJson, err := base64.StdEncoding.DecodeString(matches[1])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding evaluation summary: %s", err)
+	}
+
+	summary := newEvaluationSummary()
+	if err := json.Unmarshal(summaryJson, summary); err != nil {
+		return nil, fmt.Errorf("error unmarshalling evaluation summary: %s", err)
+	}
+
+	return summary, nil
+}
+
+// renderEvaluationChanges compares the current policy results to the previous ones. Policies that weren't part of
+// the previous evaluation are treated as newly failing or newly passing, and policies that are no longer evaluated
+// (e.g., because they were unassigned from the policy group) are listed with their previous result.
+func renderEvaluationChanges(previous, current *evaluationSummary) string {
+	var newlyFailing, newlyPassing, unchanged, removed []string
+	for _, name := range sortedPolicyNames(current) {
+		pass := current.Policies[name]
+		previousPass, ok := previous.Policies[name]
+
+		switch {
+		case ok && previousPass == pass:
+			unchanged = append(unchanged, fmt.Sprintf("%s %s", asCode(name), statusMessage(pass)))
+		case pass:
+			newlyPassing = append(newlyPassing, asCode(name))
+		default:
+			newlyFailing = append(newlyFailing, asCode(name))
+		}
+	}
+
+	for _, name := range sortedPolicyNames(previous) {
+		if _, ok := current.Policies[name]; !ok {
+			removed = append(removed, fmt.Sprintf("%s (previously %s)", asCode(name), statusMessage(previous.Policies[name])))
+		}
+	}
+
+	md := markdownPrinter{}
+	md.newline().h2("Changes since last evaluation")
+
+	for _, section := range []struct {
+		title    string
+		policies []string
+	}{
+		{"Newly Failing", newlyFailing},
+		{"Newly Passing", newlyPassing},
+		{"Unchanged", unchanged},
+		{"No longer evaluated", removed},
+	} {
+		md.h3(section.title)
+		if len(section.policies) == 0 {
+			md.write("None").newline()
+			continue
+		}
+
+		md.list(section.policies).newline()
+	}
+
+	return md.string()
+}
+
+func sortedPolicyNames(summary *evaluationSummary) []string {
+	var names []string
+	for name := range summary.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
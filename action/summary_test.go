@@ -0,0 +1,101 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("evaluation summary", func() {
+	Context("parseEvaluationSummary", func() {
+		It("should parse a summary embedded in a report", func() {
+			expected := newEvaluationSummary()
+			expected.Policies[fake.LetterN(10)] = true
+			expected.Policies[fake.LetterN(10)] = false
+
+			encoded, err := expected.encode()
+			Expect(err).NotTo(HaveOccurred())
+			report := fmt.Sprintf("# %s\n<!---%s%s--->\n", fake.Sentence(3), evaluationSummaryCommentPrefix, encoded)
+
+			actual, err := parseEvaluationSummary(report)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		})
+
+		It("should return nil when the report doesn't contain a summary", func() {
+			actual, err := parseEvaluationSummary(fake.Sentence(10))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(BeNil())
+		})
+
+		It("should return an error when the summary is malformed", func() {
+			report := fmt.Sprintf("<!---%s%s--->", evaluationSummaryCommentPrefix, "e30K=bm90IGpzb24=")
+
+			actual, err := parseEvaluationSummary(report)
+
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(BeNil())
+		})
+	})
+
+	Context("renderEvaluationChanges", func() {
+		It("should group policies by how their results changed", func() {
+			previous := &evaluationSummary{Policies: map[string]bool{
+				"a": true,
+				"b": false,
+				"c": true,
+				"d": true,
+			}}
+			current := &evaluationSummary{Policies: map[string]bool{
+				"a": false,
+				"b": true,
+				"c": true,
+				"e": false,
+			}}
+
+			actual := renderEvaluationChanges(previous, current)
+
+			Expect(actual).To(ContainSubstring("## Changes since last evaluation"))
+			Expect(actual).To(ContainSubstring("### Newly Failing\n\n- `a`\n- `e`\n"))
+			Expect(actual).To(ContainSubstring("### Newly Passing\n\n- `b`\n"))
+			Expect(actual).To(ContainSubstring("### Unchanged\n\n- `c` ✅ (PASSED)\n"))
+			Expect(actual).To(ContainSubstring("### No longer evaluated\n\n- `d` (previously ✅ (PASSED))\n"))
+		})
+
+		It("should list policies that are no longer evaluated", func() {
+			previous := &evaluationSummary{Policies: map[string]bool{"a": true, "b": false}}
+			current := &evaluationSummary{Policies: map[string]bool{"a": true}}
+
+			actual := renderEvaluationChanges(previous, current)
+
+			Expect(actual).To(ContainSubstring("### No longer evaluated\n\n- `b` (previously ❌ (FAILED))\n"))
+			Expect(actual).To(ContainSubstring("### Newly Failing\n\nNone\n"))
+		})
+
+		It("should note when a section is empty", func() {
+			summary := &evaluationSummary{Policies: map[string]bool{"a": true}}
+
+			actual := renderEvaluationChanges(summary, summary)
+
+			Expect(actual).To(ContainSubstring("### Newly Failing\n\nNone\n"))
+			Expect(actual).To(ContainSubstring("### No longer evaluated\n\nNone\n"))
+		})
+	})
+})
@@ -0,0 +1,118 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rode/enforcer-action/intoto"
+	"github.com/rode/enforcer-action/resource"
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"go.uber.org/zap"
+)
+
+const (
+	verifierId   = "https://github.com/rode/enforcer-action"
+	verifierName = "enforcer-action"
+)
+
+// version is set at build time
+var version = "development"
+
+// createVerificationSummaries signs a SLSA Verification Summary Attestation for each resource that can be identified by a digest.
+// The DSSE envelopes are returned one per line, matching the .intoto.jsonl convention.
+func (a *EnforcerAction) createVerificationSummaries(policyGroup string, evaluations []*resourceEvaluation) (string, error) {
+	keyPem, err := osReadFile(a.config.Vsa.SigningKey)
+	if err != nil {
+		return "", fmt.Errorf("error reading VSA signing key: %s", err)
+	}
+
+	signer, err := intoto.NewSigner(keyPem, a.config.Vsa.KeyId)
+	if err != nil {
+		return "", err
+	}
+
+	var envelopes bytes.Buffer
+	for _, evaluation := range evaluations {
+		resourceUri := evaluation.result.ResourceEvaluation.ResourceVersion.Version
+		subject := verificationSubject(resourceUri)
+		if subject == nil {
+			a.logger.Warn("Skipping verification summary for a resource without a digest", zap.String("resourceUri", resourceUri))
+			continue
+		}
+
+		result := intoto.VerificationFailed
+		levels := []string{intoto.VerificationFailed}
+		if evaluation.pass() {
+			result = intoto.VerificationPassed
+			levels = a.config.Vsa.VerifiedLevels
+		}
+
+		statement, err := intoto.NewVerificationSummaryStatement(subject, &intoto.VerificationSummary{
+			Verifier: &intoto.Verifier{
+				Id:      verifierId,
+				Version: map[string]string{verifierName: version},
+			},
+			TimeVerified:       timeNow().UTC(),
+			ResourceUri:        resourceUri,
+			Policy:             &intoto.VerificationPolicy{Uri: a.policyGroupUri(policyGroup)},
+			VerificationResult: result,
+			VerifiedLevels:     levels,
+		})
+		if err != nil {
+			return "", fmt.Errorf("error creating verification summary: %s", err)
+		}
+
+		envelope, err := signer.Sign(statement)
+		if err != nil {
+			return "", err
+		}
+
+		line, err := json.Marshal(envelope)
+		if err != nil {
+			return "", fmt.Errorf("error encoding verification summary: %s", err)
+		}
+		envelopes.Write(line)
+		envelopes.WriteString("\n")
+	}
+
+	return envelopes.String(), nil
+}
+
+func (a *EnforcerAction) policyGroupUri(policyGroup string) string {
+	return fmt.Sprintf("rode://%s/policy-groups/%s", a.config.ClientConfig.Rode.Host, policyGroup)
+}
+
+// verificationSubject identifies a resource by its digest, since that's what an admission controller can check
+func verificationSubject(resourceUri string) *intoto.Subject {
+	uri, err := resource.Parse(resourceUri)
+	if err != nil {
+		return nil
+	}
+
+	switch uri.Type {
+	case rode.ResourceType_DOCKER:
+		return &intoto.Subject{Name: uri.Name, Digest: map[string]string{"sha256": strings.TrimPrefix(uri.Version, "sha256:")}}
+	case rode.ResourceType_FILE:
+		return &intoto.Subject{Name: uri.Name, Digest: map[string]string{"sha256": uri.Version}}
+	case rode.ResourceType_GIT:
+		return &intoto.Subject{Name: uri.Name, Digest: map[string]string{"sha1": uri.Version}}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	rode "github.com/rode/rode/proto/v1alpha1"
+	grafeas_common_proto "github.com/rode/rode/protodeps/grafeas/proto/v1beta1/common_go_proto"
+	"go.uber.org/zap"
+)
+
+const occurrencesPageSize = 1000
+
+var (
+	timeNow   = time.Now
+	timeAfter = time.After
+)
+
+// waitForOccurrences polls Rode until the resource has an occurrence of every configured kind or note, since scanners may
+// upload their results after the action starts. If the timeout elapses, the resource is still evaluated and a warning is reported.
+func (a *EnforcerAction) waitForOccurrences(ctx context.Context, resourceUri string) error {
+	if len(a.config.Wait.Occurrences) == 0 {
+		return nil
+	}
+
+	start := timeNow()
+	deadline := start.Add(a.config.Wait.Timeout)
+	for attempt := 1; ; attempt++ {
+		missing, err := a.missingOccurrences(ctx, resourceUri)
+		if err != nil {
+			return err
+		}
+
+		if len(missing) == 0 {
+			a.logger.Info("Found required occurrences", zap.String("resourceUri", resourceUri), zap.Duration("waited", timeNow().Sub(start).Round(time.Second)))
+			return nil
+		}
+
+		remaining := deadline.Sub(timeNow())
+		if remaining <= 0 {
+			a.logger.Warn("Timed out waiting for occurrences", zap.String("resourceUri", resourceUri), zap.Strings("missing", missing))
+			a.warnings = append(a.warnings, fmt.Sprintf("Timed out after %s waiting for %s occurrences for %s", a.config.Wait.Timeout, strings.Join(missing, ", "), asCode(resourceUri)))
+			return nil
+		}
+
+		a.logger.Info("Waiting for occurrences",
+			zap.String("resourceUri", resourceUri),
+			zap.Strings("missing", missing),
+			zap.Int("attempt", attempt),
+			zap.Duration("remaining", remaining.Round(time.Second)),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for occurrences for %s: %s", resourceUri, ctx.Err())
+		case <-timeAfter(minDuration(a.config.Wait.Interval, remaining)):
+		}
+	}
+}
+
+// missingOccurrences returns the configured kinds or note names that don't have an occurrence for the resource yet
+func (a *EnforcerAction) missingOccurrences(ctx context.Context, resourceUri string) ([]string, error) {
+	found := map[string]bool{}
+	pageToken := ""
+	for {
+		response, err := a.client.ListVersionedResourceOccurrences(ctx, &rode.ListVersionedResourceOccurrencesRequest{
+			ResourceUri: resourceUri,
+			PageSize:    occurrencesPageSize,
+			PageToken:   pageToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing occurrences for %s: %s", resourceUri, err)
+		}
+
+		for _, occurrence := range response.Occurrences {
+			found[occurrence.Kind.String()] = true
+			found[occurrence.NoteName] = true
+			found[path.Base(occurrence.NoteName)] = true
+		}
+
+		pageToken = response.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+
+	var missing []string
+	for _, required := range a.config.Wait.Occurrences {
+		if !found[occurrenceKey(required)] {
+			missing = append(missing, required)
+		}
+	}
+
+	return missing, nil
+}
+
+// occurrenceKey normalizes note kinds, which are case-insensitive; anything else is treated as a note name
+func occurrenceKey(required string) string {
+	if _, ok := grafeas_common_proto.NoteKind_value[strings.ToUpper(required)]; ok {
+		return strings.ToUpper(required)
+	}
+
+	return required
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// NewClientCredentialsTokenSource requests access tokens from an OAuth2 token endpoint using the client credentials grant.
+// Tokens are reused until they expire, and then a new one is requested.
+func NewClientCredentialsTokenSource(client *http.Client, clientId, clientSecret, tokenUrl string, scopes []string) oauth2.TokenSource {
+	clientCredentials := &clientcredentials.Config{
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenUrl,
+		Scopes:       scopes,
+	}
+
+	return clientCredentials.TokenSource(context.WithValue(context.Background(), oauth2.HTTPClient, client))
+}
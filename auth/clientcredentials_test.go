@@ -0,0 +1,117 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
+)
+
+var _ = Describe("Client credentials token source", func() {
+	var (
+		server      *httptest.Server
+		tokenSource oauth2.TokenSource
+
+		clientId     string
+		clientSecret string
+		scopes       []string
+		expiresIn    int
+		requests     int
+	)
+
+	BeforeEach(func() {
+		clientId = fake.LetterN(10)
+		clientSecret = fake.LetterN(20)
+		scopes = []string{"rode", "enforcer"}
+		expiresIn = 3600
+		requests = 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			Expect(r.ParseForm()).To(Succeed())
+			Expect(r.PostForm.Get("grant_type")).To(Equal("client_credentials"))
+			Expect(r.PostForm.Get("scope")).To(Equal("rode enforcer"))
+
+			w.Header().Set("Content-Type", "application/json")
+			username, password, _ := r.BasicAuth()
+			if username != clientId || password != clientSecret {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error": "unauthorized_client"}`)
+				return
+			}
+
+			fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, requests, expiresIn)
+		}))
+	})
+
+	JustBeforeEach(func() {
+		tokenSource = NewClientCredentialsTokenSource(server.Client(), clientId, clientSecret, server.URL, scopes)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should request an access token with the client credentials", func() {
+		token, err := tokenSource.Token()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token.AccessToken).To(Equal("token-1"))
+	})
+
+	It("should reuse the access token until it expires", func() {
+		_, err := tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+		token, err := tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(token.AccessToken).To(Equal("token-1"))
+		Expect(requests).To(Equal(1))
+	})
+
+	When("the access token expires", func() {
+		BeforeEach(func() {
+			// tokens are treated as expired shortly before their expiry
+			expiresIn = 1
+		})
+
+		It("should request a new token", func() {
+			_, err := tokenSource.Token()
+			Expect(err).NotTo(HaveOccurred())
+			token, err := tokenSource.Token()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(token.AccessToken).To(Equal("token-2"))
+			Expect(requests).To(Equal(2))
+		})
+	})
+
+	When("the client credentials are rejected", func() {
+		JustBeforeEach(func() {
+			tokenSource = NewClientCredentialsTokenSource(server.Client(), clientId, fake.LetterN(10), server.URL, scopes)
+		})
+
+		It("should return an error", func() {
+			_, err := tokenSource.Token()
+
+			Expect(err).To(MatchError(ContainSubstring("unauthorized_client")))
+		})
+	})
+})
@@ -0,0 +1,76 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenCredentials adds a bearer token from the token source to every request to Rode
+type TokenCredentials struct {
+	source                   oauth2.TokenSource
+	requireTransportSecurity bool
+}
+
+func NewTokenCredentials(source oauth2.TokenSource, requireTransportSecurity bool) *TokenCredentials {
+	return &TokenCredentials{
+		source:                   source,
+		requireTransportSecurity: requireTransportSecurity,
+	}
+}
+
+func (t *TokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	token, err := t.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("error getting token for Rode: %s", err)
+	}
+
+	return map[string]string{
+		"authorization": "Bearer " + token.AccessToken,
+	}, nil
+}
+
+func (t *TokenCredentials) RequireTransportSecurity() bool {
+	return t.requireTransportSecurity
+}
+
+// BasicCredentials adds a basic authorization header to every request to Rode
+type BasicCredentials struct {
+	username string
+	password string
+}
+
+func NewBasicCredentials(username, password string) *BasicCredentials {
+	return &BasicCredentials{
+		username: username,
+		password: password,
+	}
+}
+
+func (b *BasicCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	token := base64.StdEncoding.EncodeToString([]byte(b.username + ":" + b.password))
+
+	return map[string]string{
+		"authorization": "Basic " + token,
+	}, nil
+}
+
+func (b *BasicCredentials) RequireTransportSecurity() bool {
+	return true
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	jwtTokenType           = "urn:ietf:params:oauth:token-type:jwt"
+	accessTokenType        = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+type exchangeTokenSource struct {
+	client   *http.Client
+	subject  oauth2.TokenSource
+	tokenUrl string
+	clientId string
+	scopes   []string
+}
+
+type tokenExchangeResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// NewExchangeTokenSource exchanges tokens from the subject token source for access tokens at an OAuth2 token endpoint,
+// following RFC 8693. Access tokens are reused until they expire.
+func NewExchangeTokenSource(client *http.Client, subject oauth2.TokenSource, tokenUrl, clientId string, scopes []string) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &exchangeTokenSource{
+		client:   client,
+		subject:  subject,
+		tokenUrl: tokenUrl,
+		clientId: clientId,
+		scopes:   scopes,
+	})
+}
+
+func (e *exchangeTokenSource) Token() (*oauth2.Token, error) {
+	subjectToken, err := e.subject.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":           {tokenExchangeGrantType},
+		"subject_token":        {subjectToken.AccessToken},
+		"subject_token_type":   {jwtTokenType},
+		"requested_token_type": {accessTokenType},
+	}
+	if e.clientId != "" {
+		form.Set("client_id", e.clientId)
+	}
+	if len(e.scopes) > 0 {
+		form.Set("scope", strings.Join(e.scopes, " "))
+	}
+
+	response, err := e.client.PostForm(e.tokenUrl, form)
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging token: %s", err)
+	}
+	defer response.Body.Close()
+
+	var exchanged tokenExchangeResponse
+	if err := json.NewDecoder(response.Body).Decode(&exchanged); err != nil {
+		return nil, fmt.Errorf("error decoding token exchange response (status %d): %s", response.StatusCode, err)
+	}
+
+	if response.StatusCode != http.StatusOK || exchanged.AccessToken == "" {
+		return nil, fmt.Errorf("token exchange failed with status %d: %s", response.StatusCode, strings.TrimSpace(exchanged.Error+" "+exchanged.ErrorDescription))
+	}
+
+	token := &oauth2.Token{
+		AccessToken: exchanged.AccessToken,
+		TokenType:   exchanged.TokenType,
+	}
+	if exchanged.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(exchanged.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
+)
+
+var _ = Describe("Exchange token source", func() {
+	var (
+		server      *httptest.Server
+		tokenSource oauth2.TokenSource
+
+		subjectToken string
+		accessToken  string
+		clientId     string
+		scopes       []string
+		requests     int
+	)
+
+	BeforeEach(func() {
+		subjectToken = fake.LetterN(20)
+		accessToken = fake.LetterN(20)
+		clientId = ""
+		scopes = nil
+		requests = 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			Expect(r.ParseForm()).To(Succeed())
+			Expect(r.PostForm.Get("grant_type")).To(Equal(tokenExchangeGrantType))
+			Expect(r.PostForm.Get("subject_token_type")).To(Equal(jwtTokenType))
+			Expect(r.PostForm.Get("requested_token_type")).To(Equal(accessTokenType))
+			Expect(r.PostForm.Get("client_id")).To(Equal(clientId))
+			Expect(r.PostForm.Get("scope")).To(Equal(strings.Join(scopes, " ")))
+
+			w.Header().Set("Content-Type", "application/json")
+			if r.PostForm.Get("subject_token") != subjectToken {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error": "invalid_grant", "error_description": "subject token is invalid"}`)
+				return
+			}
+
+			fmt.Fprintf(w, `{"access_token": "%s", "token_type": "Bearer", "expires_in": 300}`, accessToken)
+		}))
+	})
+
+	JustBeforeEach(func() {
+		subject := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: subjectToken})
+		tokenSource = NewExchangeTokenSource(server.Client(), subject, server.URL, clientId, scopes)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should exchange the subject token for an access token", func() {
+		token, err := tokenSource.Token()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token.AccessToken).To(Equal(accessToken))
+		Expect(token.Expiry).To(BeTemporally("~", time.Now().Add(5*time.Minute), time.Second))
+	})
+
+	It("should reuse the access token until it expires", func() {
+		_, err := tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests).To(Equal(1))
+	})
+
+	When("a client id and scopes are configured", func() {
+		BeforeEach(func() {
+			clientId = fake.LetterN(10)
+			scopes = []string{"rode", "enforcer"}
+		})
+
+		It("should include them in the request", func() {
+			token, err := tokenSource.Token()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal(accessToken))
+		})
+	})
+
+	When("the token endpoint rejects the subject token", func() {
+		JustBeforeEach(func() {
+			subject := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: fake.LetterN(10)})
+			tokenSource = NewExchangeTokenSource(server.Client(), subject, server.URL, clientId, scopes)
+		})
+
+		It("should return the error from the token endpoint", func() {
+			_, err := tokenSource.Token()
+
+			Expect(err).To(MatchError("token exchange failed with status 400: invalid_grant subject token is invalid"))
+		})
+	})
+})
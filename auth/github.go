@@ -0,0 +1,119 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type gitHubIdTokenSource struct {
+	client       *http.Client
+	requestUrl   string
+	requestToken string
+	audience     string
+}
+
+type gitHubIdTokenResponse struct {
+	Value string `json:"value"`
+}
+
+// NewGitHubIdTokenSource requests OIDC ID tokens from the GitHub Actions runner, using the ACTIONS_ID_TOKEN_REQUEST_URL
+// and ACTIONS_ID_TOKEN_REQUEST_TOKEN that are available when the workflow has the id-token: write permission.
+// Tokens are reused until they expire.
+func NewGitHubIdTokenSource(client *http.Client, requestUrl, requestToken, audience string) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &gitHubIdTokenSource{
+		client:       client,
+		requestUrl:   requestUrl,
+		requestToken: requestToken,
+		audience:     audience,
+	})
+}
+
+func (g *gitHubIdTokenSource) Token() (*oauth2.Token, error) {
+	requestUrl, err := url.Parse(g.requestUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token request url: %s", err)
+	}
+
+	if g.audience != "" {
+		query := requestUrl.Query()
+		query.Set("audience", g.audience)
+		requestUrl.RawQuery = query.Encode()
+	}
+
+	request, err := http.NewRequest(http.MethodGet, requestUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+g.requestToken)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := g.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting GitHub ID token: %s", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %d requesting GitHub ID token: %s", response.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var idToken gitHubIdTokenResponse
+	if err := json.NewDecoder(response.Body).Decode(&idToken); err != nil {
+		return nil, fmt.Errorf("error decoding GitHub ID token response: %s", err)
+	}
+
+	if idToken.Value == "" {
+		return nil, fmt.Errorf("GitHub ID token response didn't include a token")
+	}
+
+	return &oauth2.Token{
+		AccessToken: idToken.Value,
+		TokenType:   "Bearer",
+		Expiry:      jwtExpiry(idToken.Value),
+	}, nil
+}
+
+// jwtExpiry reads the exp claim without verifying the token, which is left to the server. A zero time is returned if there isn't one.
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.Exp, 0)
+}
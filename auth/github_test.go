@@ -0,0 +1,127 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
+)
+
+var _ = Describe("GitHub ID token source", func() {
+	var (
+		server      *httptest.Server
+		tokenSource oauth2.TokenSource
+
+		audience     string
+		requestToken string
+		idToken      string
+		status       int
+		requests     int
+	)
+
+	BeforeEach(func() {
+		audience = fake.URL()
+		requestToken = fake.LetterN(20)
+		idToken = fakeJwt(time.Now().Add(time.Hour))
+		status = http.StatusOK
+		requests = 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if r.Header.Get("Authorization") != "Bearer "+requestToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			Expect(r.URL.Query().Get("api-version")).To(Equal("2.0"))
+			Expect(r.URL.Query().Get("audience")).To(Equal(audience))
+
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"value": "%s"}`, idToken)
+		}))
+	})
+
+	JustBeforeEach(func() {
+		tokenSource = NewGitHubIdTokenSource(server.Client(), server.URL+"?api-version=2.0", requestToken, audience)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should request an ID token for the audience", func() {
+		token, err := tokenSource.Token()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token.AccessToken).To(Equal(idToken))
+	})
+
+	It("should use the expiry of the ID token", func() {
+		expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+		idToken = fakeJwt(expiry)
+
+		token, err := tokenSource.Token()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token.Expiry).To(BeTemporally("==", expiry))
+	})
+
+	It("should reuse the ID token until it expires", func() {
+		_, err := tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests).To(Equal(1))
+	})
+
+	When("the request token is rejected", func() {
+		JustBeforeEach(func() {
+			tokenSource = NewGitHubIdTokenSource(server.Client(), server.URL+"?api-version=2.0", fake.LetterN(10), audience)
+		})
+
+		It("should return an error", func() {
+			_, err := tokenSource.Token()
+
+			Expect(err).To(MatchError(ContainSubstring("unexpected status 401")))
+		})
+	})
+
+	When("the response doesn't include a token", func() {
+		BeforeEach(func() {
+			idToken = ""
+		})
+
+		It("should return an error", func() {
+			_, err := tokenSource.Token()
+
+			Expect(err).To(MatchError(ContainSubstring("didn't include a token")))
+		})
+	})
+})
+
+func fakeJwt(expiry time.Time) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	claims := fmt.Sprintf(`{"sub": "repo:%s", "exp": %d}`, fake.Username(), expiry.Unix())
+
+	return encode([]byte(`{"alg": "RS256"}`)) + "." + encode([]byte(claims)) + "." + encode([]byte(fake.LetterN(10)))
+}
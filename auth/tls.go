@@ -0,0 +1,75 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// NewTLSConfig builds the TLS configuration for the Rode connection. The CA bundle, client certificate and client key
+// can each be a path to a PEM file or the PEM content itself, so they can be passed from secrets as environment variables.
+func NewTLSConfig(caBundle, clientCert, clientKey, serverName string) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: serverName,
+		// gRPC requires HTTP/2
+		NextProtos: []string{"h2"},
+	}
+
+	if caBundle != "" {
+		caPem, err := readPem(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %s", err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(caPem) {
+			return nil, errors.New("CA bundle doesn't contain any PEM encoded certificates")
+		}
+	}
+
+	if clientCert != "" || clientKey != "" {
+		certPem, err := readPem(clientCert)
+		if err != nil {
+			return nil, fmt.Errorf("error reading client certificate: %s", err)
+		}
+
+		keyPem, err := readPem(clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error reading client key: %s", err)
+		}
+
+		certificate, err := tls.X509KeyPair(certPem, keyPem)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %s", err)
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+func readPem(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+
+	return os.ReadFile(value)
+}
@@ -0,0 +1,201 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TLS", func() {
+	var (
+		ctx = context.Background()
+
+		caPem         string
+		clientCertPem string
+		clientKeyPem  string
+		listener      net.Listener
+	)
+
+	BeforeEach(func() {
+		ca, caKey := newCertificate(nil, nil, "ca", true)
+		caPem = encodeCertificate(ca)
+
+		serverCert, serverKey := newCertificate(ca, caKey, "rode.internal", false)
+		clientCert, clientKey := newCertificate(ca, caKey, "enforcer-action", false)
+		clientCertPem = encodeCertificate(clientCert)
+		clientKeyPem = encodeKey(clientKey)
+
+		clientCAs := x509.NewCertPool()
+		clientCAs.AddCert(ca)
+
+		var err error
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs,
+			NextProtos:   []string{"h2"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		go func() {
+			defer GinkgoRecover()
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				_ = conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	dial := func(config *tls.Config) (*tls.Conn, error) {
+		dialer := &tls.Dialer{Config: config}
+		conn, err := dialer.DialContext(ctx, "tcp", listener.Addr().String())
+		if err != nil {
+			return nil, err
+		}
+
+		return conn.(*tls.Conn), nil
+	}
+
+	It("should connect with the CA bundle, client certificate and server name override from PEM content", func() {
+		config, err := NewTLSConfig(caPem, clientCertPem, clientKeyPem, "rode.internal")
+		Expect(err).NotTo(HaveOccurred())
+
+		conn, err := dial(config)
+
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		Expect(conn.ConnectionState().NegotiatedProtocol).To(Equal("h2"))
+	})
+
+	It("should read PEM files", func() {
+		directory, err := os.MkdirTemp("", "enforcer-action-tls")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(directory)
+		write := func(name, content string) string {
+			filePath := filepath.Join(directory, name)
+			Expect(os.WriteFile(filePath, []byte(content), 0600)).To(Succeed())
+			return filePath
+		}
+
+		config, err := NewTLSConfig(write("ca.pem", caPem), write("client.pem", clientCertPem), write("client-key.pem", clientKeyPem), "rode.internal")
+		Expect(err).NotTo(HaveOccurred())
+
+		conn, err := dial(config)
+
+		Expect(err).NotTo(HaveOccurred())
+		conn.Close()
+	})
+
+	It("should verify the server name", func() {
+		config, err := NewTLSConfig(caPem, clientCertPem, clientKeyPem, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = dial(config)
+
+		Expect(err).To(MatchError(ContainSubstring("127.0.0.1")))
+	})
+
+	It("should verify the server certificate against the CA bundle", func() {
+		otherCa, _ := newCertificate(nil, nil, "other", true)
+		config, err := NewTLSConfig(encodeCertificate(otherCa), clientCertPem, clientKeyPem, "rode.internal")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = dial(config)
+
+		Expect(err).To(MatchError(ContainSubstring("unknown authority")))
+	})
+
+	It("should return an error when the CA bundle doesn't contain certificates", func() {
+		_, err := NewTLSConfig("-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----", "", "", "")
+
+		Expect(err).To(MatchError(ContainSubstring("CA bundle")))
+	})
+
+	It("should return an error when the client key doesn't match the certificate", func() {
+		_, otherKey := newCertificate(nil, nil, "other", true)
+
+		_, err := NewTLSConfig(caPem, clientCertPem, encodeKey(otherKey), "")
+
+		Expect(err).To(MatchError(ContainSubstring("error loading client certificate")))
+	})
+
+	It("should return an error when a file doesn't exist", func() {
+		_, err := NewTLSConfig(fake.LetterN(10), "", "", "")
+
+		Expect(err).To(MatchError(ContainSubstring("error reading CA bundle")))
+	})
+})
+
+// newCertificate creates a certificate signed by the parent, or a self-signed certificate if there isn't one
+func newCertificate(parent *x509.Certificate, parentKey *ecdsa.PrivateKey, name string, isCa bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(int64(fake.Uint32()) + 1),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCa,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).NotTo(HaveOccurred())
+	certificate, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+
+	return certificate, key
+}
+
+func encodeCertificate(certificate *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+}
+
+func encodeKey(key *ecdsa.PrivateKey) string {
+	der, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+}
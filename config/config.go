@@ -17,63 +17,611 @@ package config
 import (
 	"errors"
 	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/peterbourgon/ff/v3"
+	"github.com/rode/enforcer-action/resource"
 	"github.com/rode/rode/common"
 )
 
+const (
+	OutputJson     = "json"
+	OutputMarkdown = "markdown"
+	OutputTable    = "table"
+)
+
+const (
+	CommandEvaluate     = "evaluate"
+	CommandReport       = "report"
+	CommandListPolicies = "policies list"
+	CommandTestPolicies = "policies test"
+	CommandListGroups   = "groups list"
+)
+
+const (
+	PolicyVersionDriftFail = "fail"
+	PolicyVersionDriftWarn = "warn"
+)
+
 type GitHubConfig struct {
+	Actions    bool
 	EventName  string
 	EventPath  string
 	RunId      int
 	ServerUrl  string
 	Repository string
+	Sha        string
+	Ref        string
+	HeadRef    string
+	Workflow   string
+	Actor      string
 	Token      string
 	Workspace  string
+	OutputFile string
+}
+
+type RegistryConfig struct {
+	ResolveDigest bool
+	DockerConfig  string
+	Insecure      bool
+}
+
+type WaitConfig struct {
+	Occurrences []string
+	Timeout     time.Duration
+	Interval    time.Duration
+}
+
+type VsaConfig struct {
+	SigningKey     string
+	KeyId          string
+	Path           string
+	VerifiedLevels []string
+}
+
+type GitHubOidcConfig struct {
+	Enabled          bool
+	Audience         string
+	RequestUrl       string
+	RequestToken     string
+	TokenExchangeUrl string
+	ClientId         string
+	Scopes           []string
+}
+
+type TlsConfig struct {
+	CaBundle   string
+	ClientCert string
+	ClientKey  string
+	ServerName string
+}
+
+// IsConfigured is true when the connection to Rode needs more than the default TLS configuration
+func (t *TlsConfig) IsConfigured() bool {
+	return t.CaBundle != "" || t.ClientCert != "" || t.ClientKey != "" || t.ServerName != ""
 }
 
 type Config struct {
-	AccessToken  string
-	GitHub       *GitHubConfig
-	Enforce      bool
-	PolicyGroup  string
-	ResourceUri  string
-	ClientConfig *common.ClientConfig
+	Command             string
+	EvaluationId        string
+	PolicyFiles         []string
+	ConfigFile          string
+	AccessToken         string
+	GitHubOidc          *GitHubOidcConfig
+	GitHub              *GitHubConfig
+	Enforce             bool
+	PolicyGroup         string
+	CommandPolicyGroups []string
+	ResourceUri         string
+	ResourceUriBuilt    bool
+	Manifests           []string
+	BuildMetadata       string
+	EvaluateCommit      bool
+	BaselineResourceUri string
+	FailOnRegression    bool
+	MinPolicies         int
+	RequiredPolicies    []string
+	PolicyVersions      map[string]uint32
+	PolicyVersionDrift  string
+	DecoratePullRequest bool
+	RecordDecision      bool
+	UploadProvenance    bool
+	ProvenanceFile      string
+	Environment         string
+	Rules               []*Rule
+	Rule                *Rule
+	Registry            *RegistryConfig
+	Wait                *WaitConfig
+	Vsa                 *VsaConfig
+	Tls                 *TlsConfig
+	GatewayUrl          string
+	Output              string
+	ClientConfig        *common.ClientConfig
 }
 
 func Build(name string, args []string) (*Config, error) {
-	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	command, args, err := parseCommand(args)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := flag.NewFlagSet(name+" "+command, flag.ContinueOnError)
 	c := &Config{
+		Command:      command,
 		ClientConfig: common.SetupRodeClientFlags(flags),
 		GitHub:       &GitHubConfig{},
+		Registry:     &RegistryConfig{},
+		Wait:         &WaitConfig{},
+		Vsa:          &VsaConfig{},
+		GitHubOidc:   &GitHubOidcConfig{},
+		Tls:          &TlsConfig{},
 	}
 
 	flags.StringVar(&c.AccessToken, "access-token", "", "An access token that will be included in requests to Rode.")
+	flags.BoolVar(&c.GitHubOidc.Enabled, "github-oidc", false, "When set, requests to Rode are authenticated with a GitHub Actions OIDC ID token, or an access token exchanged for one. Requires the id-token: write permission.")
+	flags.StringVar(&c.GitHubOidc.Audience, "github-oidc-audience", "", "The audience of the GitHub ID token. Defaults to GitHub's default audience, the URL of the repository owner.")
+	flags.StringVar(&c.GitHubOidc.TokenExchangeUrl, "github-oidc-token-exchange-url", "", "An OAuth2 token endpoint that exchanges the GitHub ID token for an access token. When unset, the ID token is sent to Rode directly.")
+	flags.StringVar(&c.GitHubOidc.ClientId, "github-oidc-client-id", "", "A client id included in token exchange requests.")
+	oidcScopes := flags.String("github-oidc-scopes", "", "Scopes to request in the token exchange, separated by commas or newlines.")
+	flags.StringVar(&c.GitHubOidc.RequestUrl, "actions-id-token-request-url", "", "The URL for requesting a GitHub ID token. This is set automatically when running in GitHub Actions with the id-token: write permission.")
+	flags.StringVar(&c.GitHubOidc.RequestToken, "actions-id-token-request-token", "", "The token used to request a GitHub ID token. This is set automatically when running in GitHub Actions with the id-token: write permission.")
+	flags.StringVar(&c.Tls.CaBundle, "rode-ca-bundle", "", "A PEM encoded CA bundle used to verify Rode's certificate, either as a path or the PEM content.")
+	flags.StringVar(&c.Tls.ClientCert, "rode-client-cert", "", "A PEM encoded client certificate presented to Rode, either as a path or the PEM content. Requires rode-client-key.")
+	flags.StringVar(&c.Tls.ClientKey, "rode-client-key", "", "The PEM encoded private key of rode-client-cert, either as a path or the PEM content.")
+	flags.StringVar(&c.Tls.ServerName, "rode-server-name", "", "Overrides the server name used to verify Rode's certificate. Defaults to the host of rode-host.")
+	flags.StringVar(&c.GatewayUrl, "rode-gateway-url", "", "The URL of Rode's HTTP/JSON gateway (e.g., https://rode.example.com). When set, Rode is called through the gateway instead of gRPC, using the proxy in HTTPS_PROXY.")
 	flags.BoolVar(&c.Enforce, "enforce", true, "Controls whether the step should fail if the evaluation fails.")
 	flags.StringVar(&c.PolicyGroup, "policy-group", "", "The policy group to evaluate the resource against.")
+	commandPolicyGroups := flags.String("command-policy-groups", "", "Policy groups, separated by commas or newlines, that a /rode evaluate comment may select instead of policy-group. When unset, the command can't change the policy group.")
 	flags.StringVar(&c.ResourceUri, "resource-uri", "", "The resource to evaluate policy against.")
+	flags.BoolVar(&c.ResourceUriBuilt, "resource-uri-built", false, "When set, resource-uri is attributed to this workflow run, so upload-provenance creates a build occurrence for it.")
+	coordinates := &packageCoordinates{}
+	flags.StringVar(&coordinates.npm, "npm-package", "", "An npm package to evaluate, as name@version. Used instead of resource-uri.")
+	flags.StringVar(&coordinates.pip, "pip-package", "", "A pip package to evaluate, as name@version or name==version. Used instead of resource-uri.")
+	flags.StringVar(&coordinates.nuget, "nuget-package", "", "A NuGet package to evaluate, as name@version. Used instead of resource-uri.")
+	flags.StringVar(&coordinates.maven, "maven-package", "", "A Maven artifact to evaluate, as group:artifact:version. Used instead of resource-uri.")
+	flags.StringVar(&coordinates.gitCommit, "git-commit", "", "A commit sha in github-repository to evaluate. Used instead of resource-uri.")
+	flags.BoolVar(&c.EvaluateCommit, "evaluate-commit", false, "When set, the commit the workflow is running against is evaluated as a git resource. For pull requests, this is the head commit of the pull request.")
+	manifests := flags.String("manifests", "", "Kubernetes manifests (including helm template or kustomize build output) or docker-compose files, as files, directories, or globs separated by commas or newlines. Every image they declare is evaluated.")
+	flags.StringVar(&c.BuildMetadata, "build-metadata", "", "The metadata output of docker/build-push-action, either as JSON or a path to a file. A digest URI is evaluated for every pushed image.")
+	flags.StringVar(&c.BaselineResourceUri, "baseline-resource-uri", "", "A resource to compare against, evaluated with the same policy group (e.g., the version currently deployed from the base branch).")
+	flags.BoolVar(&c.FailOnRegression, "fail-on-regression", false, "When set, the step only fails if a policy that passed for the baseline resource fails for the resource. Requires baseline-resource-uri.")
+	flags.IntVar(&c.MinPolicies, "min-policies", 1, "The fewest policies that must be evaluated for each resource. Evaluating fewer fails the resource, so that a policy group with missing assignments doesn't pass.")
+	requiredPolicies := flags.String("required-policies", "", "Policy names or ids, separated by commas or newlines, that must be evaluated for each resource.")
+	policyVersions := flags.String("policy-versions", "", "The expected version of assigned policies, as name=version pairs (or policy version ids) separated by commas or newlines. Policies can be named by name or id.")
+	flags.StringVar(&c.PolicyVersionDrift, "policy-version-drift", PolicyVersionDriftFail, "What to do when an assigned policy doesn't match its version in policy-versions: fail or warn.")
+	flags.BoolVar(&c.DecoratePullRequest, "decorate-pull-request", true, "When set, the evaluation report is added to the pull request as a comment.")
+	flags.BoolVar(&c.RecordDecision, "record-decision", false, "When set, the gate decision for each resource is recorded in Rode as a discovery occurrence.")
+	flags.StringVar(&c.Environment, "environment", "", "The environment the evaluated resources are being deployed to. Included in recorded decisions.")
+	flags.BoolVar(&c.UploadProvenance, "upload-provenance", false, "When set, a build occurrence describing the workflow run is created for any images in build-metadata, and resource-uri when resource-uri-built is set, before evaluating.")
+	flags.StringVar(&c.ProvenanceFile, "provenance-file", "", "An in-toto or SLSA provenance statement, optionally in a DSSE envelope, to include in uploaded build occurrences. Implies upload-provenance.")
+	flags.BoolVar(&c.Registry.ResolveDigest, "resolve-digest", false, "When set, image tags are resolved to a sha256 digest using the registry API before evaluating.")
+	flags.StringVar(&c.Registry.DockerConfig, "docker-config", "", "A directory containing a Docker config.json with registry credentials. Defaults to ~/.docker.")
+	flags.BoolVar(&c.Registry.Insecure, "registry-insecure", false, "When set, registries are contacted over plain HTTP.")
+	flags.StringVar(&c.Vsa.SigningKey, "vsa-signing-key", "", "A PEM encoded ECDSA, Ed25519 or RSA private key. When set, a signed SLSA Verification Summary Attestation is written for each evaluated resource.")
+	flags.StringVar(&c.Vsa.KeyId, "vsa-key-id", "", "The key id included in VSA signatures. Defaults to the sha256 digest of the public key.")
+	flags.StringVar(&c.Vsa.Path, "vsa-path", "vsa.intoto.jsonl", "Where to write the signed VSAs, relative to github-workspace.")
+	vsaVerifiedLevels := flags.String("vsa-verified-levels", "SLSA_BUILD_LEVEL_0", "The SLSA levels, separated by commas or newlines, that the policy group verifies. Included in VSAs for resources that pass.")
+	waitFor := flags.String("wait-for", "", "Note kinds (e.g., VULNERABILITY or ATTESTATION) or note names, separated by commas or newlines. Evaluation waits until the resource has an occurrence of each.")
+	flags.DurationVar(&c.Wait.Timeout, "wait-timeout", 5*time.Minute, "How long to wait for the occurrences in wait-for before evaluating anyway.")
+	flags.DurationVar(&c.Wait.Interval, "wait-interval", 10*time.Second, "How often to check for the occurrences in wait-for.")
+	flags.BoolVar(&c.GitHub.Actions, "github-actions", false, "Whether the enforcer is running in GitHub Actions. This is set automatically when running in GitHub Actions; otherwise, GitHub setup is skipped and results are printed for a terminal.")
 	flags.StringVar(&c.GitHub.ServerUrl, "github-server-url", "", "The GitHub server url. This is set automatically when running in GitHub Actions.")
 	flags.StringVar(&c.GitHub.Repository, "github-repository", "", "An org/repo slug. This is set automatically when running in GitHub Actions.")
+	flags.StringVar(&c.GitHub.Sha, "github-sha", "", "The commit sha that triggered the workflow. This is set automatically when running in GitHub Actions.")
+	flags.StringVar(&c.GitHub.Ref, "github-ref", "", "The ref that triggered the workflow. This is set automatically when running in GitHub Actions.")
+	flags.StringVar(&c.GitHub.HeadRef, "github-head-ref", "", "The head branch of the pull request that triggered the workflow. This is set automatically when running in GitHub Actions.")
+	flags.StringVar(&c.GitHub.Workflow, "github-workflow", "", "The name of the workflow. This is set automatically when running in GitHub Actions.")
+	flags.StringVar(&c.GitHub.Actor, "github-actor", "", "The user that triggered the workflow. This is set automatically when running in GitHub Actions.")
 	flags.IntVar(&c.GitHub.RunId, "github-run-id", 0, "The run id of a workflow. This is set automatically when running in GitHub Actions.")
 	flags.StringVar(&c.GitHub.Token, "github-token", "", "a GitHub access token used to leave comments on pull requests.")
 	flags.StringVar(&c.GitHub.EventName, "github-event-name", "", "the name of the event triggering the action")
 	flags.StringVar(&c.GitHub.EventPath, "github-event-path", "", "path to the GitHub event payload")
 	flags.StringVar(&c.GitHub.Workspace, "github-workspace", "", "GitHub Actions working directory")
+	flags.StringVar(&c.GitHub.OutputFile, "github-output", "", "The file that step outputs are written to. This is set automatically when running in GitHub Actions.")
 
-	if err := ff.Parse(flags, args, ff.WithEnvVarNoPrefix()); err != nil {
+	flags.StringVar(&c.Output, "output", "", "How to print the evaluation results: json, markdown or table. Defaults to markdown in GitHub Actions and table otherwise.")
+
+	var group *string
+	if command == CommandListPolicies {
+		group = flags.String("group", "", "The policy group to list the policies of. Defaults to policy-group.")
+	}
+
+	configFile := flags.String("config", defaultConfigFile, "A YAML file that sets any of these flags, using either the flag name or the action input name (e.g., policyGroup) as the key. Flags and environment variables take precedence over the file.")
+
+	configFileParser := newConfigFileParser(configFile, flags)
+	if err := ff.Parse(flags, args,
+		ff.WithEnvVarNoPrefix(),
+		ff.WithConfigFileFlag("config"),
+		ff.WithConfigFileParser(configFileParser.parse),
+		ff.WithAllowMissingConfigFile(true),
+	); err != nil {
 		return nil, err
 	}
 
+	if err := c.parseArguments(flags); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		if _, err := os.Stat(*configFile); err == nil {
+			c.ConfigFile = *configFile
+		} else if *configFile != defaultConfigFile {
+			return nil, fmt.Errorf("error reading config file: %s", err)
+		}
+	}
+
+	c.Rules = configFileParser.rules
+	if command != CommandEvaluate {
+		c.Rules = nil
+	}
+
+	if c.Rule = c.matchRule(); c.Rule != nil {
+		if c.Rule.PolicyGroup != "" && !configFileParser.explicit["policy-group"] {
+			c.PolicyGroup = c.Rule.PolicyGroup
+		}
+
+		if c.Rule.Enforce != nil && !configFileParser.explicit["enforce"] {
+			c.Enforce = *c.Rule.Enforce
+		}
+	}
+
+	if group != nil && *group != "" {
+		c.PolicyGroup = *group
+	}
+
 	c.PolicyGroup = strings.TrimSpace(c.PolicyGroup)
+	c.Manifests = splitList(*manifests)
+	c.Wait.Occurrences = splitList(*waitFor)
+	c.RequiredPolicies = splitList(*requiredPolicies)
+	c.CommandPolicyGroups = splitList(*commandPolicyGroups)
+	if c.PolicyVersions, err = parsePolicyVersions(splitList(*policyVersions)); err != nil {
+		return nil, err
+	}
+	c.GitHubOidc.Scopes = splitList(*oidcScopes)
+	c.Vsa.VerifiedLevels = splitList(*vsaVerifiedLevels)
+	c.UploadProvenance = c.UploadProvenance || c.ProvenanceFile != ""
 
-	if c.PolicyGroup == "" {
-		return nil, errors.New("must set policy-group")
+	if c.Output == "" {
+		c.Output = OutputTable
+		if c.GitHub.Actions {
+			c.Output = OutputMarkdown
+		}
 	}
 
-	if c.ResourceUri == "" {
-		return nil, errors.New("must set resource-uri")
+	if c.Output != OutputJson && c.Output != OutputMarkdown && c.Output != OutputTable {
+		return nil, fmt.Errorf("output must be one of %s, %s or %s, got %q", OutputJson, OutputMarkdown, OutputTable, c.Output)
+	}
+
+	if err := c.validateGitHubOidc(); err != nil {
+		return nil, err
+	}
+
+	if err := c.validateClientCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := c.validateGatewayUrl(); err != nil {
+		return nil, err
+	}
+
+	if (c.Tls.ClientCert == "") != (c.Tls.ClientKey == "") {
+		return nil, errors.New("rode-client-cert and rode-client-key must be set together")
+	}
+
+	if c.Tls.IsConfigured() && c.ClientConfig.Rode.DisableTransportSecurity {
+		return nil, errors.New("rode-ca-bundle, rode-client-cert, rode-client-key and rode-server-name can't be used with rode-insecure-disable-transport-security")
+	}
+
+	if c.Tls.IsConfigured() && c.ClientConfig.ProxyAuth {
+		return nil, errors.New("rode-ca-bundle, rode-client-cert, rode-client-key and rode-server-name can't be used with proxy-auth")
+	}
+
+	if c.MinPolicies < 0 {
+		return nil, errors.New("min-policies can't be negative")
+	}
+
+	if c.PolicyVersionDrift != PolicyVersionDriftFail && c.PolicyVersionDrift != PolicyVersionDriftWarn {
+		return nil, fmt.Errorf("policy-version-drift must be %s or %s, got %q", PolicyVersionDriftFail, PolicyVersionDriftWarn, c.PolicyVersionDrift)
+	}
+
+	switch command {
+	case CommandEvaluate:
+		if err := c.validateEvaluation(coordinates); err != nil {
+			return nil, err
+		}
+	case CommandListPolicies:
+		if c.PolicyGroup == "" {
+			return nil, errors.New("must set group or policy-group")
+		}
+	case CommandTestPolicies:
+		if err := c.validatePolicyTest(coordinates); err != nil {
+			return nil, err
+		}
 	}
 
 	return c, nil
 }
+
+// validatePolicyTest checks that there are local policies and a single resource to dry-run them against
+func (c *Config) validatePolicyTest(coordinates *packageCoordinates) error {
+	if len(c.PolicyFiles) == 0 {
+		return errors.New("policies test requires Rego files, directories or globs to evaluate")
+	}
+
+	if err := c.buildResourceUri(coordinates); err != nil {
+		return err
+	}
+
+	if c.ResourceUri == "" {
+		return errors.New("policies test requires resource-uri or package coordinates to evaluate the policies against")
+	}
+
+	return c.validateResourceUri("resource-uri", c.ResourceUri)
+}
+
+// validateEvaluation checks the options for choosing what to evaluate, which only the evaluate command needs
+func (c *Config) validateEvaluation(coordinates *packageCoordinates) error {
+	if c.PolicyGroup == "" {
+		return errors.New("must set policy-group")
+	}
+
+	if err := c.buildResourceUri(coordinates); err != nil {
+		return err
+	}
+
+	if c.ResourceUri == "" && !c.EvaluateCommit && len(c.Manifests) == 0 && c.BuildMetadata == "" {
+		return errors.New("must set resource-uri, package coordinates, evaluate-commit, manifests, or build-metadata")
+	}
+
+	if c.ResourceUriBuilt && c.ResourceUri == "" {
+		return errors.New("must set resource-uri when resource-uri-built is set")
+	}
+
+	if c.BaselineResourceUri != "" && c.ResourceUri == "" {
+		return errors.New("must set resource-uri when baseline-resource-uri is set")
+	}
+
+	if c.FailOnRegression && c.BaselineResourceUri == "" {
+		return errors.New("must set baseline-resource-uri when fail-on-regression is enabled")
+	}
+
+	if len(c.Wait.Occurrences) > 0 && (c.Wait.Timeout <= 0 || c.Wait.Interval <= 0) {
+		return errors.New("wait-timeout and wait-interval must be positive when wait-for is set")
+	}
+
+	if err := c.validateResourceUri("resource-uri", c.ResourceUri); err != nil {
+		return err
+	}
+
+	if err := c.validateResourceUri("baseline-resource-uri", c.BaselineResourceUri); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseCommand splits the subcommand from its flags. Without a subcommand, the resource is evaluated, which is how the action runs.
+func parseCommand(args []string) (string, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return CommandEvaluate, args, nil
+	}
+
+	switch args[0] {
+	case CommandEvaluate, CommandReport:
+		return args[0], args[1:], nil
+	case "policies", "groups":
+		subcommands := "groups list"
+		if args[0] == "policies" {
+			subcommands = "policies list or policies test"
+		}
+
+		if len(args) < 2 {
+			return "", nil, fmt.Errorf("%s requires a subcommand, expected %s", args[0], subcommands)
+		}
+
+		command := args[0] + " " + args[1]
+		if command != CommandListPolicies && command != CommandTestPolicies && command != CommandListGroups {
+			return "", nil, fmt.Errorf("unknown command %q, expected %s", command, subcommands)
+		}
+
+		return command, args[2:], nil
+	}
+
+	return "", nil, fmt.Errorf("unknown command %q, expected one of %s, %s, %s, %s or %s", args[0], CommandEvaluate, CommandReport, CommandListPolicies, CommandTestPolicies, CommandListGroups)
+}
+
+// parseArguments reads the evaluation id of the report command. Flags may follow it, so they're parsed again.
+func (c *Config) parseArguments(flags *flag.FlagSet) error {
+	arguments := flags.Args()
+	if c.Command == CommandReport {
+		if len(arguments) == 0 {
+			return errors.New("report requires an evaluation id")
+		}
+
+		c.EvaluationId = arguments[0]
+		if err := flags.Parse(arguments[1:]); err != nil {
+			return err
+		}
+		arguments = flags.Args()
+	}
+
+	// policy files may be interleaved with flags
+	for c.Command == CommandTestPolicies && len(arguments) > 0 {
+		c.PolicyFiles = append(c.PolicyFiles, arguments[0])
+		if err := flags.Parse(arguments[1:]); err != nil {
+			return err
+		}
+		arguments = flags.Args()
+	}
+
+	if len(arguments) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(arguments, " "))
+	}
+
+	return nil
+}
+
+func (c *Config) validateGitHubOidc() error {
+	if !c.GitHubOidc.Enabled {
+		return nil
+	}
+
+	if c.AccessToken != "" || c.ClientConfig.OIDCAuth.ClientID != "" || c.ClientConfig.BasicAuth.Username != "" || c.ClientConfig.ProxyAuth {
+		return errors.New("github-oidc can't be used with another authentication method")
+	}
+
+	if c.GitHubOidc.RequestUrl == "" || c.GitHubOidc.RequestToken == "" {
+		return errors.New("github-oidc requires actions-id-token-request-url and actions-id-token-request-token, which are only set when the workflow has the id-token: write permission")
+	}
+
+	return nil
+}
+
+func (c *Config) validateClientCredentials() error {
+	oidc := c.ClientConfig.OIDCAuth
+	if oidc.ClientID == "" && oidc.ClientSecret == "" && oidc.TokenURL == "" {
+		return nil
+	}
+
+	if oidc.ClientID == "" || oidc.ClientSecret == "" || oidc.TokenURL == "" {
+		return errors.New("oidc-client-id, oidc-client-secret and oidc-token-url must all be set to use client credentials")
+	}
+
+	if c.AccessToken != "" {
+		return errors.New("only one of access-token or oidc-client-id may be set")
+	}
+
+	if c.ClientConfig.BasicAuth.Username != "" || c.ClientConfig.ProxyAuth {
+		return errors.New("basic-auth-username and proxy-auth can't be used with oidc-client-id")
+	}
+
+	return nil
+}
+
+func (c *Config) validateGatewayUrl() error {
+	if c.GatewayUrl == "" {
+		return nil
+	}
+
+	gatewayUrl, err := url.Parse(c.GatewayUrl)
+	if err != nil || (gatewayUrl.Scheme != "https" && gatewayUrl.Scheme != "http") || gatewayUrl.Host == "" {
+		return fmt.Errorf("rode-gateway-url must be an http or https URL, got %q", c.GatewayUrl)
+	}
+
+	if c.ClientConfig.BasicAuth.Username != "" || c.ClientConfig.ProxyAuth {
+		return errors.New("basic-auth-username and proxy-auth can't be used with rode-gateway-url")
+	}
+
+	return nil
+}
+
+type packageCoordinates struct {
+	npm       string
+	pip       string
+	nuget     string
+	maven     string
+	gitCommit string
+}
+
+// buildResourceUri sets the resource uri from package coordinates, so that users don't need to know Rode's uri formats
+func (c *Config) buildResourceUri(coordinates *packageCoordinates) error {
+	builders := []struct {
+		flag  string
+		value string
+		build func(string) (string, error)
+	}{
+		{"npm-package", coordinates.npm, resource.NpmUri},
+		{"pip-package", coordinates.pip, resource.PipUri},
+		{"nuget-package", coordinates.nuget, resource.NugetUri},
+		{"maven-package", coordinates.maven, resource.MavenUri},
+		{"git-commit", coordinates.gitCommit, func(commit string) (string, error) {
+			return resource.GitUri(c.GitHub.ServerUrl, c.GitHub.Repository, commit)
+		}},
+	}
+
+	source := "resource-uri"
+	for _, builder := range builders {
+		if builder.value == "" {
+			continue
+		}
+
+		if c.ResourceUri != "" {
+			return fmt.Errorf("only one of %s or %s may be set", source, builder.flag)
+		}
+
+		uri, err := builder.build(strings.TrimSpace(builder.value))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", builder.flag, err)
+		}
+		c.ResourceUri = uri
+		source = builder.flag
+	}
+
+	return nil
+}
+
+func (c *Config) validateResourceUri(flag, uri string) error {
+	if uri == "" {
+		return nil
+	}
+
+	_, err := resource.Parse(uri)
+	if err == nil || (errors.Is(err, resource.ErrUnpinnedImage) && c.Registry.ResolveDigest) {
+		return nil
+	}
+
+	if errors.Is(err, resource.ErrUnpinnedImage) {
+		return fmt.Errorf("invalid %s: %s; pin the image by digest or enable resolve-digest", flag, err)
+	}
+
+	return fmt.Errorf("invalid %s: %s", flag, err)
+}
+
+// parsePolicyVersions reads policy-versions entries, which are either name=version or a policy version id
+// (<policy id>.<version>), into a map of policy name or id to version
+func parsePolicyVersions(entries []string) (map[string]uint32, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	versions := map[string]uint32{}
+	for _, entry := range entries {
+		separator := strings.LastIndex(entry, "=")
+		if separator == -1 {
+			separator = strings.LastIndex(entry, ".")
+		}
+
+		invalid := fmt.Errorf("policy-versions entries must be name=version or a policy version id, got %q", entry)
+		if separator < 1 {
+			return nil, invalid
+		}
+
+		policy := strings.TrimSpace(entry[:separator])
+		version, err := strconv.ParseUint(strings.TrimSpace(entry[separator+1:]), 10, 32)
+		if policy == "" || err != nil || version == 0 {
+			return nil, invalid
+		}
+
+		versions[policy] = uint32(version)
+	}
+
+	return versions, nil
+}
+
+// splitList splits a flag value on commas and newlines, which allows multi-line action inputs
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -15,8 +15,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
@@ -27,8 +29,14 @@ import (
 var _ = Describe("Config", func() {
 	Context("Build", func() {
 		var (
-			expectedPolicyGroup = fake.URL()
-			expectedResourceUri = fake.LetterN(10)
+			expectedPolicyGroup         = fake.URL()
+			expectedResourceUri         = fmt.Sprintf("%s/%s@sha256:%s", fake.DomainName(), fake.LetterN(10), fake.Regex("[a-f0-9]{64}"))
+			expectedBaselineResourceUri = fmt.Sprintf("%s/%s@sha256:%s", fake.DomainName(), fake.LetterN(10), fake.Regex("[a-f0-9]{64}"))
+			expectedImageTag            = fmt.Sprintf("%s/%s:%s", fake.DomainName(), fake.LetterN(10), fake.AppVersion())
+			expectedCommit              = fake.Regex("[a-f0-9]{40}")
+			expectedDockerConfig        = fake.LetterN(10)
+			expectedBuildMetadata       = fmt.Sprintf(`{"containerimage.digest": "sha256:%s"}`, fake.LetterN(64))
+			expectedPolicyId            = fake.UUID()
 		)
 
 		type testCase struct {
@@ -53,8 +61,18 @@ var _ = Describe("Config", func() {
 					"--resource-uri=" + expectedResourceUri,
 				},
 				expected: &Config{
-					Enforce: true,
-					GitHub:  populateGitHubConfig(),
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
 					ClientConfig: &common.ClientConfig{
 						Rode: &common.RodeClientConfig{
 							Host: "rode:50051",
@@ -73,8 +91,18 @@ var _ = Describe("Config", func() {
 					"--resource-uri=" + expectedResourceUri,
 				},
 				expected: &Config{
-					Enforce: true,
-					GitHub:  populateGitHubConfig(),
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
 					ClientConfig: &common.ClientConfig{
 						Rode: &common.RodeClientConfig{
 							Host: "rode:50051",
@@ -99,14 +127,1056 @@ var _ = Describe("Config", func() {
 				},
 				expectError: true,
 			}),
+			Entry("baseline comparison", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--baseline-resource-uri=" + expectedBaselineResourceUri,
+					"--fail-on-regression",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri:         expectedResourceUri,
+					PolicyGroup:         expectedPolicyGroup,
+					BaselineResourceUri: expectedBaselineResourceUri,
+					FailOnRegression:    true,
+				},
+			}),
+			Entry("digest resolution", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedImageTag,
+					"--resolve-digest",
+					"--docker-config=" + expectedDockerConfig,
+					"--registry-insecure",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry: &RegistryConfig{
+						ResolveDigest: true,
+						DockerConfig:  expectedDockerConfig,
+						Insecure:      true,
+					},
+					Wait:       defaultWaitConfig(),
+					Vsa:        defaultVsaConfig(),
+					GitHubOidc: populateGitHubOidcConfig(),
+					Tls:        &TlsConfig{},
+					Output:     defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedImageTag,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("npm package", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--npm-package=@rode/demo@1.2.3",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: "npm://@rode/demo:1.2.3",
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("git commit", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--git-commit=" + expectedCommit,
+					"--github-server-url=https://github.com",
+					"--github-repository=rode/enforcer-action",
+				},
+				expected: &Config{
+					Command:            CommandEvaluate,
+					MinPolicies:        1,
+					PolicyVersionDrift: PolicyVersionDriftFail,
+					Enforce:            true,
+					GitHub: func() *GitHubConfig {
+						c := populateGitHubConfig()
+						c.ServerUrl = "https://github.com"
+						c.Repository = "rode/enforcer-action"
+
+						return c
+					}(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: "git://github.com/rode/enforcer-action@" + expectedCommit,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("workflow commit", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--evaluate-commit",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					EvaluateCommit:      true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("waiting for occurrences", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--wait-for=VULNERABILITY, attestation",
+					"--wait-timeout=2m",
+					"--wait-interval=30s",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait: &WaitConfig{
+						Occurrences: []string{"VULNERABILITY", "attestation"},
+						Timeout:     2 * time.Minute,
+						Interval:    30 * time.Second,
+					},
+					Vsa:        defaultVsaConfig(),
+					GitHubOidc: populateGitHubOidcConfig(),
+					Tls:        &TlsConfig{},
+					Output:     defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("waiting without an interval", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--wait-for=VULNERABILITY",
+					"--wait-interval=0s",
+				},
+				expectError: true,
+			}),
+			Entry("recording decisions", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--record-decision",
+					"--environment=prod",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					RecordDecision:      true,
+					Environment:         "prod",
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("provenance file", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--provenance-file=provenance.json",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					UploadProvenance:    true,
+					ProvenanceFile:      "provenance.json",
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("resource uri built by the workflow", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--upload-provenance",
+					"--resource-uri-built",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					UploadProvenance:    true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri:      expectedResourceUri,
+					ResourceUriBuilt: true,
+					PolicyGroup:      expectedPolicyGroup,
+				},
+			}),
+			Entry("resource uri built without a resource uri", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--evaluate-commit",
+					"--resource-uri-built",
+				},
+				expectError: true,
+			}),
+			Entry("verification summary", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--vsa-signing-key=key.pem",
+					"--vsa-key-id=enforcer",
+					"--vsa-path=out/vsa.jsonl",
+					"--vsa-verified-levels=SLSA_BUILD_LEVEL_3,SLSA_SOURCE_LEVEL_2",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa: &VsaConfig{
+						SigningKey:     "key.pem",
+						KeyId:          "enforcer",
+						Path:           "out/vsa.jsonl",
+						VerifiedLevels: []string{"SLSA_BUILD_LEVEL_3", "SLSA_SOURCE_LEVEL_2"},
+					},
+					GitHubOidc: populateGitHubOidcConfig(),
+					Tls:        &TlsConfig{},
+					Output:     defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("github oidc", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--github-oidc",
+					"--github-oidc-audience=rode",
+					"--github-oidc-token-exchange-url=https://sts.example.com/token",
+					"--github-oidc-client-id=enforcer",
+					"--github-oidc-scopes=rode,enforcer",
+					"--actions-id-token-request-url=http://localhost:8080/token",
+					"--actions-id-token-request-token=request-token",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc: &GitHubOidcConfig{
+						Enabled:          true,
+						Audience:         "rode",
+						RequestUrl:       "http://localhost:8080/token",
+						RequestToken:     "request-token",
+						TokenExchangeUrl: "https://sts.example.com/token",
+						ClientId:         "enforcer",
+						Scopes:           []string{"rode", "enforcer"},
+					},
+					Tls:    &TlsConfig{},
+					Output: defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("github oidc without an id token request url", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--github-oidc",
+					"--actions-id-token-request-url=",
+					"--actions-id-token-request-token=",
+				},
+				expectError: true,
+			}),
+			Entry("github oidc with an access token", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--github-oidc",
+					"--access-token=" + fake.LetterN(10),
+					"--actions-id-token-request-url=http://localhost:8080/token",
+					"--actions-id-token-request-token=request-token",
+				},
+				expectError: true,
+			}),
+			Entry("client credentials", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--oidc-client-id=enforcer",
+					"--oidc-client-secret=secret",
+					"--oidc-token-url=https://keycloak.example.com/token",
+					"--oidc-scopes=rode enforcer",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth: &common.OIDCAuthConfig{
+							ClientID:     "enforcer",
+							ClientSecret: "secret",
+							TokenURL:     "https://keycloak.example.com/token",
+							Scopes:       "rode enforcer",
+						},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("client credentials without a token url", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--oidc-client-id=enforcer",
+					"--oidc-client-secret=secret",
+				},
+				expectError: true,
+			}),
+			Entry("client credentials with an access token", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--oidc-client-id=enforcer",
+					"--oidc-client-secret=secret",
+					"--oidc-token-url=https://keycloak.example.com/token",
+					"--access-token=" + fake.LetterN(10),
+				},
+				expectError: true,
+			}),
+			Entry("client credentials with basic auth", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--oidc-client-id=enforcer",
+					"--oidc-client-secret=secret",
+					"--oidc-token-url=https://keycloak.example.com/token",
+					"--basic-auth-username=" + fake.Username(),
+					"--basic-auth-password=" + fake.LetterN(10),
+				},
+				expectError: true,
+			}),
+			Entry("client credentials with proxy auth", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--oidc-client-id=enforcer",
+					"--oidc-client-secret=secret",
+					"--oidc-token-url=https://keycloak.example.com/token",
+					"--proxy-auth",
+				},
+				expectError: true,
+			}),
+			Entry("rode tls", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--rode-ca-bundle=ca.pem",
+					"--rode-client-cert=client.pem",
+					"--rode-client-key=client-key.pem",
+					"--rode-server-name=rode.internal",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls: &TlsConfig{
+						CaBundle:   "ca.pem",
+						ClientCert: "client.pem",
+						ClientKey:  "client-key.pem",
+						ServerName: "rode.internal",
+					},
+					Output: defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("rode client cert without a key", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--rode-client-cert=client.pem",
+				},
+				expectError: true,
+			}),
+			Entry("rode tls with transport security disabled", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--rode-ca-bundle=ca.pem",
+					"--rode-insecure-disable-transport-security",
+				},
+				expectError: true,
+			}),
+			Entry("rode tls with proxy auth", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--rode-ca-bundle=ca.pem",
+					"--proxy-auth",
+				},
+				expectError: true,
+			}),
+			Entry("rode gateway", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--rode-gateway-url=https://rode.example.com",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					GatewayUrl:          "https://rode.example.com",
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("rode gateway without a scheme", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--rode-gateway-url=rode.example.com",
+				},
+				expectError: true,
+			}),
+			Entry("rode gateway with basic auth", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--rode-gateway-url=https://rode.example.com",
+					"--basic-auth-username=" + fake.Username(),
+					"--basic-auth-password=" + fake.LetterN(10),
+				},
+				expectError: true,
+			}),
+			Entry("json output", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--output=json",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              OutputJson,
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("running in GitHub Actions", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--github-actions",
+				},
+				expected: &Config{
+					Command:            CommandEvaluate,
+					MinPolicies:        1,
+					PolicyVersionDrift: PolicyVersionDriftFail,
+					Enforce:            true,
+					GitHub: func() *GitHubConfig {
+						c := populateGitHubConfig()
+						c.Actions = true
+
+						return c
+					}(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              OutputMarkdown,
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("policy requirements", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--min-policies=3",
+					"--required-policies=no-critical-vulns,\nsigned-images",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         3,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					RequiredPolicies:    []string{"no-critical-vulns", "signed-images"},
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					ResourceUri: expectedResourceUri,
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("policy versions", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--policy-versions=signed-images=3,\n" + expectedPolicyId + ".12",
+					"--policy-version-drift=warn",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersions:      map[string]uint32{"signed-images": 3, expectedPolicyId: 12},
+					PolicyVersionDrift:  PolicyVersionDriftWarn,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					PolicyGroup: expectedPolicyGroup,
+					ResourceUri: expectedResourceUri,
+				},
+			}),
+			Entry("policy version without a version", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--policy-versions=signed-images",
+				},
+				expectError: true,
+			}),
+			Entry("policy version that isn't a number", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--policy-versions=signed-images=latest",
+				},
+				expectError: true,
+			}),
+			Entry("unknown policy version drift", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--policy-version-drift=ignore",
+				},
+				expectError: true,
+			}),
+			Entry("negative min policies", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--min-policies=-1",
+				},
+				expectError: true,
+			}),
+			Entry("unknown output", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--output=" + fake.Word(),
+				},
+				expectError: true,
+			}),
+			Entry("malformed resource uri", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=npm://" + fake.LetterN(10),
+				},
+				expectError: true,
+			}),
+			Entry("image tag without digest resolution", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedImageTag,
+				},
+				expectError: true,
+			}),
+			Entry("malformed baseline resource uri", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--baseline-resource-uri=" + fake.URL(),
+				},
+				expectError: true,
+			}),
+			Entry("package coordinates with a resource uri", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--pip-package=requests==2.25.1",
+				},
+				expectError: true,
+			}),
+			Entry("invalid package coordinates", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--maven-package=" + fake.LetterN(10),
+				},
+				expectError: true,
+			}),
+			Entry("manifests", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--manifests=deploy/*.yaml, k8s/app.yaml\n\nk8s/overlays",
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					Manifests:   []string{"deploy/*.yaml", "k8s/app.yaml", "k8s/overlays"},
+					PolicyGroup: expectedPolicyGroup,
+				},
+			}),
+			Entry("build metadata", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--build-metadata=" + expectedBuildMetadata,
+				},
+				expected: &Config{
+					Command:             CommandEvaluate,
+					MinPolicies:         1,
+					PolicyVersionDrift:  PolicyVersionDriftFail,
+					Enforce:             true,
+					GitHub:              populateGitHubConfig(),
+					DecoratePullRequest: true,
+					Registry:            &RegistryConfig{},
+					Wait:                defaultWaitConfig(),
+					Vsa:                 defaultVsaConfig(),
+					GitHubOidc:          populateGitHubOidcConfig(),
+					Tls:                 &TlsConfig{},
+					Output:              defaultOutput(),
+					ClientConfig: &common.ClientConfig{
+						Rode: &common.RodeClientConfig{
+							Host: "rode:50051",
+						},
+						OIDCAuth:  &common.OIDCAuthConfig{},
+						BasicAuth: &common.BasicAuthConfig{},
+					},
+					BuildMetadata: expectedBuildMetadata,
+					PolicyGroup:   expectedPolicyGroup,
+				},
+			}),
+			Entry("baseline without a resource uri", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--manifests=" + fake.LetterN(10),
+					"--baseline-resource-uri=" + expectedBaselineResourceUri,
+				},
+				expectError: true,
+			}),
+			Entry("fail on regression without a baseline", &testCase{
+				flags: []string{
+					"--policy-group=" + expectedPolicyGroup,
+					"--resource-uri=" + expectedResourceUri,
+					"--fail-on-regression",
+				},
+				expectError: true,
+			}),
 			Entry("invalid flag value", &testCase{
 				flags:       []string{"--enforce=foo"},
 				expectError: true,
 			}),
 		)
+
+		Context("commands", func() {
+			It("should evaluate the resource when the command is explicit", func() {
+				c, err := Build("enforcer-action", []string{"evaluate", "--policy-group=" + expectedPolicyGroup, "--resource-uri=" + expectedResourceUri})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Command).To(Equal(CommandEvaluate))
+				Expect(c.ResourceUri).To(Equal(expectedResourceUri))
+			})
+
+			It("should read the evaluation id of a report, followed by flags", func() {
+				evaluationId := fake.UUID()
+
+				c, err := Build("enforcer-action", []string{"report", evaluationId, "--output=json"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Command).To(Equal(CommandReport))
+				Expect(c.EvaluationId).To(Equal(evaluationId))
+				Expect(c.Output).To(Equal(OutputJson))
+			})
+
+			It("should read the evaluation id of a report when it follows the flags", func() {
+				evaluationId := fake.UUID()
+
+				c, err := Build("enforcer-action", []string{"report", "--rode-host=rode.example.com:443", evaluationId})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.EvaluationId).To(Equal(evaluationId))
+				Expect(c.ClientConfig.Rode.Host).To(Equal("rode.example.com:443"))
+			})
+
+			It("should require an evaluation id for a report", func() {
+				_, err := Build("enforcer-action", []string{"report", "--output=json"})
+
+				Expect(err).To(MatchError("report requires an evaluation id"))
+			})
+
+			It("should not accept more than one evaluation id", func() {
+				_, err := Build("enforcer-action", []string{"report", fake.UUID(), fake.UUID()})
+
+				Expect(err).To(MatchError(ContainSubstring("unexpected arguments")))
+			})
+
+			It("should list the policies of a group", func() {
+				c, err := Build("enforcer-action", []string{"policies", "list", "--group=" + expectedPolicyGroup})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Command).To(Equal(CommandListPolicies))
+				Expect(c.PolicyGroup).To(Equal(expectedPolicyGroup))
+			})
+
+			It("should default the group of the policies to list to policy-group", func() {
+				c, err := Build("enforcer-action", []string{"policies", "list", "--policy-group=" + expectedPolicyGroup})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.PolicyGroup).To(Equal(expectedPolicyGroup))
+			})
+
+			It("should require a group to list policies", func() {
+				_, err := Build("enforcer-action", []string{"policies", "list"})
+
+				Expect(err).To(MatchError("must set group or policy-group"))
+			})
+
+			It("should read the files of a policy test, interleaved with flags", func() {
+				c, err := Build("enforcer-action", []string{"policies", "test", "policies/", "--output=json", "extra/*.rego", "--resource-uri=" + expectedResourceUri})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Command).To(Equal(CommandTestPolicies))
+				Expect(c.PolicyFiles).To(Equal([]string{"policies/", "extra/*.rego"}))
+				Expect(c.ResourceUri).To(Equal(expectedResourceUri))
+				Expect(c.Output).To(Equal(OutputJson))
+			})
+
+			It("should require files to test policies", func() {
+				_, err := Build("enforcer-action", []string{"policies", "test", "--resource-uri=" + expectedResourceUri})
+
+				Expect(err).To(MatchError("policies test requires Rego files, directories or globs to evaluate"))
+			})
+
+			It("should require a resource to test policies against", func() {
+				_, err := Build("enforcer-action", []string{"policies", "test", "policies/"})
+
+				Expect(err).To(MatchError("policies test requires resource-uri or package coordinates to evaluate the policies against"))
+			})
+
+			It("should build the resource to test policies against from package coordinates", func() {
+				c, err := Build("enforcer-action", []string{"policies", "test", "policies/", "--npm-package=@rode/demo@1.2.3"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.ResourceUri).To(Equal("npm://@rode/demo:1.2.3"))
+			})
+
+			It("should list policy groups without any other configuration", func() {
+				c, err := Build("enforcer-action", []string{"groups", "list"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Command).To(Equal(CommandListGroups))
+			})
+
+			It("should validate the connection flags of every command", func() {
+				_, err := Build("enforcer-action", []string{"groups", "list", "--rode-gateway-url=rode.example.com"})
+
+				Expect(err).To(HaveOccurred())
+			})
+
+			DescribeTable("invalid commands", func(args ...string) {
+				_, err := Build("enforcer-action", args)
+
+				Expect(err).To(HaveOccurred())
+			},
+				Entry("unknown command", "deploy"),
+				Entry("groups without list", "groups"),
+				Entry("unknown groups command", "groups", "delete"),
+				Entry("unknown policies command", "policies", "delete"),
+				Entry("policy files for another command", "groups", "list", "policies/"),
+				Entry("unexpected arguments", "--policy-group="+expectedPolicyGroup, "--resource-uri="+expectedResourceUri, fake.Word()),
+			)
+		})
 	})
 })
 
+func defaultWaitConfig() *WaitConfig {
+	return &WaitConfig{
+		Timeout:  5 * time.Minute,
+		Interval: 10 * time.Second,
+	}
+}
+
+// GITHUB_ACTIONS is set when running the tests in CI, which changes the default output
+func defaultOutput() string {
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		return OutputMarkdown
+	}
+
+	return OutputTable
+}
+
+func defaultVsaConfig() *VsaConfig {
+	return &VsaConfig{
+		Path:           "vsa.intoto.jsonl",
+		VerifiedLevels: []string{"SLSA_BUILD_LEVEL_0"},
+	}
+}
+
+// The ACTIONS_ID_TOKEN_ environment variables will be set when running the tests in CI with the id-token permission
+func populateGitHubOidcConfig() *GitHubOidcConfig {
+	return &GitHubOidcConfig{
+		RequestUrl:   os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL"),
+		RequestToken: os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN"),
+	}
+}
+
 // The GITHUB_ environment variables will be set when running the tests in CI
 func populateGitHubConfig() *GitHubConfig {
 	runId := 0
@@ -116,12 +1186,19 @@ func populateGitHubConfig() *GitHubConfig {
 	}
 
 	return &GitHubConfig{
+		Actions:    os.Getenv("GITHUB_ACTIONS") == "true",
 		EventName:  os.Getenv("GITHUB_EVENT_NAME"),
 		EventPath:  os.Getenv("GITHUB_EVENT_PATH"),
 		RunId:      runId,
 		ServerUrl:  os.Getenv("GITHUB_SERVER_URL"),
 		Repository: os.Getenv("GITHUB_REPOSITORY"),
+		Sha:        os.Getenv("GITHUB_SHA"),
+		Ref:        os.Getenv("GITHUB_REF"),
+		HeadRef:    os.Getenv("GITHUB_HEAD_REF"),
+		Workflow:   os.Getenv("GITHUB_WORKFLOW"),
+		Actor:      os.Getenv("GITHUB_ACTOR"),
 		Token:      os.Getenv("GITHUB_TOKEN"),
 		Workspace:  os.Getenv("GITHUB_WORKSPACE"),
+		OutputFile: os.Getenv("GITHUB_OUTPUT"),
 	}
 }
@@ -0,0 +1,199 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+const defaultConfigFile = ".rode/enforcer.yaml"
+
+// configFileKeyAliases maps config file keys to flags for the action inputs that aren't named after their flag
+var configFileKeyAliases = map[string]string{
+	"rodeInsecure": "rode-insecure-disable-transport-security",
+}
+
+// configFileListSeparators is how the items of a YAML list are joined for the flags that accept several values
+var configFileListSeparators = map[string]string{
+	"manifests":             "\n",
+	"wait-for":              "\n",
+	"github-oidc-scopes":    "\n",
+	"oidc-scopes":           " ",
+	"required-policies":     "\n",
+	"command-policy-groups": "\n",
+	"policy-versions":       "\n",
+	"vsa-verified-levels":   "\n",
+}
+
+// configFileMapKeys are the flags whose value may also be a YAML map, which is joined as key=value items
+var configFileMapKeys = map[string]bool{
+	"policy-versions": true,
+}
+
+// configFileParser is an ff config file parser for YAML files whose keys are action input names, like policyGroup,
+// or flag names. Errors name the file and the offending key.
+type configFileParser struct {
+	path  *string
+	flags *flag.FlagSet
+	// explicit are the flags set by arguments or environment variables, which take precedence over the file
+	explicit map[string]bool
+	rules    []*Rule
+}
+
+func newConfigFileParser(path *string, flags *flag.FlagSet) *configFileParser {
+	return &configFileParser{
+		path:     path,
+		flags:    flags,
+		explicit: map[string]bool{},
+	}
+}
+
+func (p *configFileParser) parse(r io.Reader, set func(name, value string) error) error {
+	// ff parses arguments and environment variables before the config file
+	p.flags.Visit(func(f *flag.Flag) {
+		p.explicit[f.Name] = true
+	})
+
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %s", *p.path, err)
+	}
+
+	var value interface{}
+	if err := yaml.Unmarshal(contents, &value); err != nil {
+		return fmt.Errorf("%s: invalid YAML: %s", *p.path, err)
+	}
+
+	if _, ok := value.(map[interface{}]interface{}); value != nil && !ok {
+		return fmt.Errorf("%s: expected a map of keys to values", *p.path)
+	}
+
+	// a map slice keeps the order of the keys, so that the first invalid key is reported
+	var document yaml.MapSlice
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return fmt.Errorf("%s: invalid YAML: %s", *p.path, err)
+	}
+
+	for _, item := range document {
+		key := fmt.Sprint(item.Key)
+		if key == "rules" {
+			if p.rules, err = parseRules(item.Value); err != nil {
+				return fmt.Errorf("%s: %s", *p.path, err)
+			}
+			continue
+		}
+
+		name := configFileFlagName(key)
+		value, err := configFileValue(name, item.Value)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %s", *p.path, key, err)
+		}
+
+		if err = set(name, value); err != nil {
+			return fmt.Errorf("%s: %s: %s", *p.path, key, configFileSetError(err, value))
+		}
+	}
+
+	return nil
+}
+
+// configFileFlagName converts a camel case key to the name of its flag (e.g., policyGroup to policy-group)
+func configFileFlagName(key string) string {
+	if alias, ok := configFileKeyAliases[key]; ok {
+		return alias
+	}
+
+	var name strings.Builder
+	for _, r := range key {
+		if unicode.IsUpper(r) {
+			name.WriteRune('-')
+			r = unicode.ToLower(r)
+		}
+		name.WriteRune(r)
+	}
+
+	return name.String()
+}
+
+func configFileValue(name string, value interface{}) (string, error) {
+	if name == "config" {
+		return "", fmt.Errorf("can't be set in a config file")
+	}
+
+	if entries, ok := value.(yaml.MapSlice); ok && configFileMapKeys[name] {
+		var items []string
+		for _, entry := range entries {
+			value, err := configFileScalar(entry.Value)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, fmt.Sprintf("%v=%s", entry.Key, value))
+		}
+
+		return strings.Join(items, configFileListSeparators[name]), nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return configFileScalar(value)
+	}
+
+	separator, ok := configFileListSeparators[name]
+	if !ok {
+		return "", fmt.Errorf("expected a single value, not a list")
+	}
+
+	var items []string
+	for _, item := range list {
+		value, err := configFileScalar(item)
+		if err != nil {
+			return "", err
+		}
+		items = append(items, value)
+	}
+
+	return strings.Join(items, separator), nil
+}
+
+func configFileScalar(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("expected a string, number or boolean")
+	}
+}
+
+// configFileSetError replaces ff's errors, which refer to flags rather than the config file key
+func configFileSetError(err error, value string) string {
+	if strings.HasSuffix(err.Error(), "not defined in flag set") {
+		return "unknown key"
+	}
+
+	return fmt.Sprintf("invalid value %q", value)
+}
@@ -0,0 +1,170 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config file", func() {
+	var (
+		directory      string
+		configFile     string
+		resourceUri    = fmt.Sprintf("%s/%s@sha256:%s", fake.DomainName(), fake.LetterN(10), fake.Regex("[a-f0-9]{64}"))
+		policyGroup    string
+		configContents string
+	)
+
+	BeforeEach(func() {
+		var err error
+		directory, err = os.MkdirTemp("", "enforcer-action-config")
+		Expect(err).NotTo(HaveOccurred())
+
+		configFile = filepath.Join(directory, "enforcer.yaml")
+		policyGroup = fake.Word()
+		configContents = fmt.Sprintf(`
+policyGroup: %s
+resourceUri: %s
+enforce: false
+decoratePullRequest: false
+manifests:
+  - k8s/
+  - compose.yaml
+waitFor: [VULNERABILITY, projects/rode/notes/build]
+wait-timeout: 2m
+rodeInsecure: true
+minPolicies: 2
+requiredPolicies:
+  - signed-images
+commandPolicyGroups: [staging, prod]
+policyVersions:
+  signed-images: 3
+  no-critical-vulnerabilities: 12
+`, policyGroup, resourceUri)
+	})
+
+	JustBeforeEach(func() {
+		Expect(os.WriteFile(configFile, []byte(configContents), 0600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(directory)).To(Succeed())
+	})
+
+	It("should set flags from input names and flag names", func() {
+		c, err := Build("enforcer-action", []string{"--config=" + configFile})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.ConfigFile).To(Equal(configFile))
+		Expect(c.PolicyGroup).To(Equal(policyGroup))
+		Expect(c.ResourceUri).To(Equal(resourceUri))
+		Expect(c.Enforce).To(BeFalse())
+		Expect(c.DecoratePullRequest).To(BeFalse())
+		Expect(c.Manifests).To(Equal([]string{"k8s/", "compose.yaml"}))
+		Expect(c.Wait.Occurrences).To(Equal([]string{"VULNERABILITY", "projects/rode/notes/build"}))
+		Expect(c.Wait.Timeout).To(Equal(2 * time.Minute))
+		Expect(c.ClientConfig.Rode.DisableTransportSecurity).To(BeTrue())
+		Expect(c.MinPolicies).To(Equal(2))
+		Expect(c.RequiredPolicies).To(Equal([]string{"signed-images"}))
+		Expect(c.CommandPolicyGroups).To(Equal([]string{"staging", "prod"}))
+		Expect(c.PolicyVersions).To(Equal(map[string]uint32{"signed-images": 3, "no-critical-vulnerabilities": 12}))
+	})
+
+	It("should prefer flags over the config file", func() {
+		expectedPolicyGroup := fake.Word()
+
+		c, err := Build("enforcer-action", []string{"--config=" + configFile, "--policy-group=" + expectedPolicyGroup, "--enforce=true"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.PolicyGroup).To(Equal(expectedPolicyGroup))
+		Expect(c.Enforce).To(BeTrue())
+		Expect(c.ResourceUri).To(Equal(resourceUri))
+	})
+
+	It("should prefer environment variables over the config file", func() {
+		expectedPolicyGroup := fake.Word()
+		Expect(os.Setenv("POLICY_GROUP", expectedPolicyGroup)).To(Succeed())
+		defer os.Unsetenv("POLICY_GROUP")
+
+		c, err := Build("enforcer-action", []string{"--config=" + configFile})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.PolicyGroup).To(Equal(expectedPolicyGroup))
+	})
+
+	When("the config file is in the default location", func() {
+		var workingDirectory string
+
+		BeforeEach(func() {
+			var err error
+			workingDirectory, err = os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.Mkdir(filepath.Join(directory, ".rode"), 0700)).To(Succeed())
+			configFile = filepath.Join(directory, ".rode", "enforcer.yaml")
+			Expect(os.Chdir(directory)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.Chdir(workingDirectory)).To(Succeed())
+		})
+
+		It("should be discovered", func() {
+			c, err := Build("enforcer-action", []string{})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.ConfigFile).To(Equal(".rode/enforcer.yaml"))
+			Expect(c.PolicyGroup).To(Equal(policyGroup))
+		})
+	})
+
+	It("should return an error when the config file doesn't exist", func() {
+		_, err := Build("enforcer-action", []string{"--config=" + filepath.Join(directory, "missing.yaml")})
+
+		Expect(err).To(MatchError(ContainSubstring("error reading config file")))
+	})
+
+	DescribeTable("invalid config files", func(contents, expectedError string) {
+		Expect(os.WriteFile(configFile, []byte(contents), 0600)).To(Succeed())
+
+		_, err := Build("enforcer-action", []string{"--config=" + configFile})
+
+		Expect(err).To(MatchError(configFile + ": " + expectedError))
+	},
+		Entry("unknown key", "policyGroup: prod\npolicyGrup: prod", "policyGrup: unknown key"),
+		Entry("invalid value", "waitTimeout: soon", `waitTimeout: invalid value "soon"`),
+		Entry("list for a single value", "policyGroup: [dev, prod]", "policyGroup: expected a single value, not a list"),
+		Entry("map value", "resourceUri:\n  uri: alpine", "resourceUri: expected a string, number or boolean"),
+		Entry("config key", "config: other.yaml", "config: can't be set in a config file"),
+		Entry("not a map", "- policyGroup", "expected a map of keys to values"),
+		Entry("invalid YAML", "policyGroup: [prod", "invalid YAML: yaml: line 1: did not find expected ',' or ']'"),
+	)
+
+	DescribeTable("flag names", func(key, expected string) {
+		Expect(configFileFlagName(key)).To(Equal(expected))
+	},
+		Entry("input name", "baselineResourceUri", "baseline-resource-uri"),
+		Entry("flag name", "resource-uri", "resource-uri"),
+		Entry("single word", "enforce", "enforce"),
+		Entry("alias", "rodeInsecure", "rode-insecure-disable-transport-security"),
+	)
+})
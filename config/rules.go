@@ -0,0 +1,143 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Rule selects the policy group and enforcement for the workflow runs it matches. Each condition that's set must match,
+// and a condition with several values matches if any of them do.
+type Rule struct {
+	Name         string     `yaml:"name"`
+	Events       stringList `yaml:"events"`
+	Branches     stringList `yaml:"branches"`
+	Refs         stringList `yaml:"refs"`
+	Environments stringList `yaml:"environments"`
+	PolicyGroup  string     `yaml:"policyGroup"`
+	Enforce      *bool      `yaml:"enforce"`
+}
+
+// stringList accepts either a single string or a list of strings
+type stringList []string
+
+func (s *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*s = []string{value}
+		return nil
+	}
+
+	var values []string
+	if err := unmarshal(&values); err != nil {
+		return errors.New("expected a string or a list of strings")
+	}
+	*s = values
+
+	return nil
+}
+
+func parseRules(value interface{}) ([]*Rule, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("rules: expected a list of rules")
+	}
+
+	var rules []*Rule
+	for i, item := range items {
+		contents, err := yaml.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+
+		rule := &Rule{}
+		if err := yaml.UnmarshalStrict(contents, rule); err != nil {
+			return nil, fmt.Errorf("rules[%d]: %s", i, strings.TrimPrefix(err.Error(), "yaml: unmarshal errors:\n  "))
+		}
+
+		if rule.PolicyGroup == "" && rule.Enforce == nil {
+			return nil, fmt.Errorf("rules[%d]: must set policyGroup or enforce", i)
+		}
+
+		for _, pattern := range append(append(append([]string{}, rule.Branches...), rule.Refs...), rule.Environments...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("rules[%d]: invalid pattern %q", i, pattern)
+			}
+		}
+
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rules[%d]", i)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// matches is true when the rule applies to the workflow run. Branches are matched against the head branch for pull requests,
+// and refs against GITHUB_REF. Patterns use path.Match syntax, so * doesn't match /.
+func (r *Rule) matches(github *GitHubConfig, environment string) bool {
+	return matchesAny(r.Events, github.EventName, false) &&
+		matchesAny(r.Branches, github.branch(), true) &&
+		matchesAny(r.Refs, github.Ref, true) &&
+		matchesAny(r.Environments, environment, true)
+}
+
+func matchesAny(patterns []string, value string, glob bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if !glob && pattern == value {
+			return true
+		}
+
+		if matched, _ := path.Match(pattern, value); glob && matched && value != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Config) matchRule() *Rule {
+	for _, rule := range c.Rules {
+		if rule.matches(c.GitHub, c.Environment) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// branch is the branch the workflow is running on, or the head branch for pull requests
+func (g *GitHubConfig) branch() string {
+	if g.HeadRef != "" {
+		return g.HeadRef
+	}
+
+	if !strings.HasPrefix(g.Ref, "refs/heads/") {
+		return ""
+	}
+
+	return strings.TrimPrefix(g.Ref, "refs/heads/")
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rules", func() {
+	var (
+		directory  string
+		configFile string
+	)
+
+	BeforeEach(func() {
+		var err error
+		directory, err = os.MkdirTemp("", "enforcer-action-rules")
+		Expect(err).NotTo(HaveOccurred())
+		configFile = filepath.Join(directory, "enforcer.yaml")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(directory)).To(Succeed())
+	})
+
+	build := func(contents string, flags ...string) (*Config, error) {
+		Expect(os.WriteFile(configFile, []byte(contents), 0600)).To(Succeed())
+		flags = append(flags, "--config="+configFile, "--resource-uri=git://github.com/rode/enforcer-action@3f9c2e1")
+
+		return Build("enforcer-action", flags)
+	}
+
+	Context("matching", func() {
+		const rules = `
+policyGroup: default
+rules:
+  - name: hotfixes
+    events: pull_request
+    branches: hotfix/*
+    enforce: true
+  - name: pull requests
+    events: [pull_request, pull_request_target]
+    enforce: false
+  - name: production
+    environments: prod*
+    policyGroup: prod
+  - refs: refs/tags/v*
+    policyGroup: release
+`
+
+		type testCase struct {
+			flags               []string
+			expectedRule        string
+			expectedPolicyGroup string
+			expectedEnforce     bool
+		}
+
+		DescribeTable("selecting a rule", func(tc *testCase) {
+			c, err := build(rules, tc.flags...)
+
+			Expect(err).NotTo(HaveOccurred())
+			if tc.expectedRule == "" {
+				Expect(c.Rule).To(BeNil())
+			} else {
+				Expect(c.Rule).NotTo(BeNil())
+				Expect(c.Rule.Name).To(Equal(tc.expectedRule))
+			}
+			Expect(c.PolicyGroup).To(Equal(tc.expectedPolicyGroup))
+			Expect(c.Enforce).To(Equal(tc.expectedEnforce))
+		},
+			Entry("pull request", &testCase{
+				flags:               []string{"--github-event-name=pull_request", "--github-ref=refs/pull/1/merge", "--github-head-ref=feature/rules"},
+				expectedRule:        "pull requests",
+				expectedPolicyGroup: "default",
+				expectedEnforce:     false,
+			}),
+			Entry("pull request from a matching branch", &testCase{
+				flags:               []string{"--github-event-name=pull_request", "--github-ref=refs/pull/1/merge", "--github-head-ref=hotfix/login"},
+				expectedRule:        "hotfixes",
+				expectedPolicyGroup: "default",
+				expectedEnforce:     true,
+			}),
+			Entry("deployment to an environment", &testCase{
+				flags:               []string{"--github-event-name=push", "--github-ref=refs/heads/main", "--environment=production"},
+				expectedRule:        "production",
+				expectedPolicyGroup: "prod",
+				expectedEnforce:     true,
+			}),
+			Entry("tag with an unnamed rule", &testCase{
+				flags:               []string{"--github-event-name=push", "--github-ref=refs/tags/v1.2.0"},
+				expectedRule:        "rules[3]",
+				expectedPolicyGroup: "release",
+				expectedEnforce:     true,
+			}),
+			Entry("no matching rule", &testCase{
+				flags:               []string{"--github-event-name=push", "--github-ref=refs/heads/main"},
+				expectedPolicyGroup: "default",
+				expectedEnforce:     true,
+			}),
+			Entry("explicit flags", &testCase{
+				flags:               []string{"--github-event-name=push", "--environment=production", "--policy-group=other", "--enforce=false"},
+				expectedRule:        "production",
+				expectedPolicyGroup: "other",
+				expectedEnforce:     false,
+			}),
+		)
+	})
+
+	DescribeTable("invalid rules", func(contents, expectedError string) {
+		_, err := build(contents)
+
+		Expect(err).To(MatchError(configFile + ": " + expectedError))
+	},
+		Entry("not a list", "policyGroup: prod\nrules:\n  enforce: false", "rules: expected a list of rules"),
+		Entry("unknown field", "policyGroup: prod\nrules:\n  - brnches: main\n    enforce: false", "rules[0]: line 1: field brnches not found in type config.Rule"),
+		Entry("no outcome", "policyGroup: prod\nrules:\n  - events: push", "rules[0]: must set policyGroup or enforce"),
+		Entry("invalid pattern", "policyGroup: prod\nrules:\n  - enforce: false\n  - branches: '[main'\n    enforce: true", `rules[1]: invalid pattern "[main"`),
+		Entry("invalid condition", "policyGroup: prod\nrules:\n  - events: {push: true}\n    enforce: true", "rules[0]: expected a string or a list of strings"),
+	)
+})
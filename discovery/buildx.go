@@ -0,0 +1,79 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rode/enforcer-action/registry"
+)
+
+const buildMetadataSource = "build metadata"
+
+// buildMetadata is the subset of the metadata output by docker/build-push-action that identifies the pushed image
+type buildMetadata struct {
+	Digest    string `json:"containerimage.digest"`
+	ImageName string `json:"image.name"`
+}
+
+// FindBuildMetadataImages derives a digest URI for every tag pushed by docker/build-push-action.
+// The metadata can either be the JSON itself or a path to a file containing it.
+func FindBuildMetadataImages(metadata string) ([]*Image, error) {
+	metadata = strings.TrimSpace(metadata)
+	contents := []byte(metadata)
+	source := buildMetadataSource
+	if !strings.HasPrefix(metadata, "{") {
+		var err error
+		contents, err = os.ReadFile(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("error reading build metadata: %s", err)
+		}
+		source = metadata
+	}
+
+	var build buildMetadata
+	if err := json.Unmarshal(contents, &build); err != nil {
+		return nil, fmt.Errorf("error parsing build metadata: %s", err)
+	}
+
+	if !strings.HasPrefix(build.Digest, "sha256:") {
+		return nil, errors.New("build metadata does not contain an image digest, was the image pushed?")
+	}
+
+	var images []*Image
+	for _, name := range strings.Split(build.ImageName, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		ref, err := registry.ParseReference(name)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing image name in build metadata: %s", err)
+		}
+
+		images = appendImage(images, &Image{Reference: ref.DigestUri(build.Digest), Source: source})
+	}
+
+	if len(images) == 0 {
+		return nil, errors.New("build metadata does not contain an image name")
+	}
+
+	return images, nil
+}
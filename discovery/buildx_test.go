@@ -0,0 +1,81 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Build metadata", func() {
+	var (
+		expectedDigest string
+		metadata       string
+	)
+
+	BeforeEach(func() {
+		expectedDigest = "sha256:" + fake.LetterN(64)
+		metadata = fmt.Sprintf(`{
+			"buildx.build.ref": "builder/builder0/abc",
+			"containerimage.config.digest": "sha256:%s",
+			"containerimage.digest": "%s",
+			"image.name": "ghcr.io/rode/app:latest,ghcr.io/rode/app:1.2.3,harbor.localhost:8443/rode/app:1.2.3"
+		}`, fake.LetterN(64), expectedDigest)
+	})
+
+	It("should derive a digest URI for every pushed image", func() {
+		actual, err := FindBuildMetadataImages(metadata)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual).To(Equal([]*Image{
+			{Reference: "ghcr.io/rode/app@" + expectedDigest, Source: buildMetadataSource},
+			{Reference: "harbor.localhost:8443/rode/app@" + expectedDigest, Source: buildMetadataSource},
+		}))
+	})
+
+	It("should read the metadata from a file", func() {
+		dir, err := os.MkdirTemp("", "metadata")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		metadataPath := writeFile(dir, "metadata.json", metadata)
+
+		actual, err := FindBuildMetadataImages(metadataPath)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual).To(HaveLen(2))
+		Expect(actual[0].Source).To(Equal(metadataPath))
+	})
+
+	It("should return an error when the image wasn't pushed", func() {
+		_, err := FindBuildMetadataImages(`{"image.name": "ghcr.io/rode/app:latest"}`)
+
+		Expect(err).To(MatchError(ContainSubstring("digest")))
+	})
+
+	It("should return an error when the metadata doesn't name an image", func() {
+		_, err := FindBuildMetadataImages(fmt.Sprintf(`{"containerimage.digest": "%s"}`, expectedDigest))
+
+		Expect(err).To(MatchError(ContainSubstring("image name")))
+	})
+
+	It("should return an error when the metadata is malformed", func() {
+		_, err := FindBuildMetadataImages("{")
+
+		Expect(err).To(MatchError(ContainSubstring("error parsing build metadata")))
+	})
+})
@@ -0,0 +1,79 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+type composeFile struct {
+	Services map[string]struct {
+		Image string `yaml:"image"`
+	} `yaml:"services"`
+}
+
+// isComposeFile distinguishes a docker-compose file from Kubernetes manifests by a document with a top-level services key
+func isComposeFile(contents []byte) bool {
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	for {
+		var topLevel map[string]interface{}
+		if err := decoder.Decode(&topLevel); err != nil {
+			return false
+		}
+
+		_, hasServices := topLevel["services"]
+		_, hasKind := topLevel["kind"]
+		if hasServices && !hasKind {
+			return true
+		}
+	}
+}
+
+// parseComposeImages returns the image of each service, sorted by service name within each document. Services that
+// are only built locally are skipped.
+func parseComposeImages(contents []byte) ([]string, error) {
+	var images []string
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	for {
+		var compose composeFile
+		err := decoder.Decode(&compose)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for name := range compose.Services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if image := compose.Services[name].Image; image != "" {
+				images = append(images, image)
+			}
+		}
+	}
+
+	return images, nil
+}
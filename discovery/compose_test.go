@@ -0,0 +1,120 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const composeFileContents = `
+version: "3.9"
+services:
+  web:
+    image: harbor.localhost/edge/web:3.1.0
+    ports:
+      - "80:80"
+  cache:
+    image: redis@sha256:7ba8cff8d3d4ec57e0b54ddd9fd1c0ecb1e5ee3e5f1f0cb5f4e4dba2a6f2d6e3
+  worker:
+    build: ./worker
+  proxy:
+    image: harbor.localhost/edge/web:3.1.0
+`
+
+var _ = Describe("Compose", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "compose")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should find the image of each service", func() {
+		composePath := writeFile(dir, "docker-compose.yml", composeFileContents)
+
+		actual, err := FindImages([]string{composePath})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual).To(Equal([]*Image{
+			{Reference: "redis@sha256:7ba8cff8d3d4ec57e0b54ddd9fd1c0ecb1e5ee3e5f1f0cb5f4e4dba2a6f2d6e3", Source: composePath},
+			{Reference: "harbor.localhost/edge/web:3.1.0", Source: composePath},
+		}))
+	})
+
+	It("should dedupe images across compose files and Kubernetes manifests", func() {
+		writeFile(dir, "compose.yaml", composeFileContents)
+		writeFile(dir, "rendered.yaml", `
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: harbor.localhost/edge/web:3.1.0
+        - name: exporter
+          image: harbor.localhost/edge/exporter:0.2.0
+`)
+
+		actual, err := FindImages([]string{dir})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual).To(HaveLen(3))
+		Expect(actual[2].Reference).To(Equal("harbor.localhost/edge/exporter:0.2.0"))
+	})
+
+	It("should find the images in every document", func() {
+		composePath := writeFile(dir, "docker-compose.yml", `
+version: "3.9"
+services:
+  web:
+    image: harbor.localhost/edge/web:3.1.0
+---
+services:
+  exporter:
+    image: harbor.localhost/edge/exporter:0.2.0
+`)
+
+		actual, err := FindImages([]string{composePath})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual).To(Equal([]*Image{
+			{Reference: "harbor.localhost/edge/web:3.1.0", Source: composePath},
+			{Reference: "harbor.localhost/edge/exporter:0.2.0", Source: composePath},
+		}))
+	})
+
+	Context("isComposeFile", func() {
+		It("should not treat Kubernetes manifests as compose files", func() {
+			Expect(isComposeFile([]byte("kind: Pod\nservices: []\n"))).To(BeFalse())
+			Expect(isComposeFile([]byte(composeFileContents))).To(BeTrue())
+		})
+
+		It("should detect services after the first document", func() {
+			Expect(isComposeFile([]byte("# shared settings\nx-logging: {}\n---\n" + composeFileContents))).To(BeTrue())
+		})
+	})
+})
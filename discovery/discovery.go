@@ -0,0 +1,54 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"fmt"
+	"os"
+)
+
+// FindImages returns the container images declared in the matching files, in the order they're found and without duplicates.
+// Patterns may be files, directories, or globs. Each file is either a docker-compose file or Kubernetes manifests, which
+// includes the output of "helm template" and "kustomize build".
+func FindImages(patterns []string) ([]*Image, error) {
+	files, err := expandPaths(patterns, ".yaml", ".yml")
+	if err != nil {
+		return nil, err
+	}
+
+	var images []*Image
+	for _, file := range files {
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %s", file, err)
+		}
+
+		parse := parseKubernetesImages
+		if isComposeFile(contents) {
+			parse = parseComposeImages
+		}
+
+		fileImages, err := parse(contents)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %s", file, err)
+		}
+
+		for _, reference := range fileImages {
+			images = appendImage(images, &Image{Reference: reference, Source: file})
+		}
+	}
+
+	return images, nil
+}
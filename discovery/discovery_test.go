@@ -0,0 +1,186 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const deploymentManifest = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  ports:
+    - port: 80
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: harbor.localhost/team/migrate:1.0.0
+      containers:
+        - name: app
+          image: harbor.localhost/team/app@sha256:54221980d01768efc835708f037a716a11a6f2f7f9633c948896a7f39f859775
+        - name: sidecar
+          image: envoyproxy/envoy:v1.18.3
+---
+`
+
+const batchManifest = `
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: report
+spec:
+  schedule: "@daily"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: report
+              image: harbor.localhost/team/report:2.0.0
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Pod
+    metadata:
+      name: debug
+    spec:
+      containers:
+        - name: debug
+          image: busybox
+  - apiVersion: apps/v1
+    kind: StatefulSet
+    metadata:
+      name: db
+    spec:
+      template:
+        spec:
+          containers:
+            - name: db
+              image: envoyproxy/envoy:v1.18.3
+---
+apiVersion: example.com/v1
+kind: Widget
+spec: not-a-pod-spec
+`
+
+var _ = Describe("Discovery", func() {
+	Context("FindImages", func() {
+		var (
+			dir            string
+			deploymentPath string
+			batchPath      string
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "manifests")
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentPath = writeFile(dir, "app/deployment.yaml", deploymentManifest)
+			batchPath = writeFile(dir, "batch.yml", batchManifest)
+			writeFile(dir, "app/README.md", fake.Sentence(5))
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("should find the images in every workload", func() {
+			actual, err := FindImages([]string{deploymentPath, batchPath})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal([]*Image{
+				{Reference: "harbor.localhost/team/migrate:1.0.0", Source: deploymentPath},
+				{Reference: "harbor.localhost/team/app@sha256:54221980d01768efc835708f037a716a11a6f2f7f9633c948896a7f39f859775", Source: deploymentPath},
+				{Reference: "envoyproxy/envoy:v1.18.3", Source: deploymentPath},
+				{Reference: "harbor.localhost/team/report:2.0.0", Source: batchPath},
+				{Reference: "busybox", Source: batchPath},
+			}))
+		})
+
+		It("should search directories for manifests", func() {
+			actual, err := FindImages([]string{dir})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(HaveLen(5))
+		})
+
+		It("should expand globs", func() {
+			actual, err := FindImages([]string{filepath.Join(dir, "*", "*.yaml")})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(HaveLen(3))
+		})
+
+		It("should dedupe images that are written differently", func() {
+			aliasPath := writeFile(dir, "alias.yaml", `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: alias
+spec:
+  containers:
+    - name: busybox
+      image: docker.io/library/busybox:latest
+`)
+
+			actual, err := FindImages([]string{aliasPath, batchPath})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal([]*Image{
+				{Reference: "docker.io/library/busybox:latest", Source: aliasPath},
+				{Reference: "harbor.localhost/team/report:2.0.0", Source: batchPath},
+				{Reference: "envoyproxy/envoy:v1.18.3", Source: batchPath},
+			}))
+		})
+
+		It("should return an error when a pattern doesn't match any files", func() {
+			_, err := FindImages([]string{filepath.Join(dir, "missing", "*.yaml")})
+
+			Expect(err).To(MatchError(ContainSubstring("no files matched")))
+		})
+
+		It("should return an error when a manifest is malformed", func() {
+			malformedPath := writeFile(dir, "malformed.yaml", "kind: Deployment\nspec: [")
+
+			_, err := FindImages([]string{malformedPath})
+
+			Expect(err).To(MatchError(ContainSubstring(malformedPath)))
+		})
+	})
+
+	Context("Image", func() {
+		It("should be pinned when it includes a digest", func() {
+			Expect((&Image{Reference: "app@sha256:abc"}).Pinned()).To(BeTrue())
+			Expect((&Image{Reference: "app:1.0.0"}).Pinned()).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,144 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+type container struct {
+	Image string `yaml:"image"`
+}
+
+type podSpec struct {
+	Containers     []container `yaml:"containers"`
+	InitContainers []container `yaml:"initContainers"`
+}
+
+type podTemplate struct {
+	Spec podSpec `yaml:"spec"`
+}
+
+// workload covers the controllers that embed a pod template: Deployments, StatefulSets, DaemonSets, ReplicaSets and Jobs
+type workload struct {
+	Spec struct {
+		Template podTemplate `yaml:"template"`
+	} `yaml:"spec"`
+}
+
+type cronJob struct {
+	Spec struct {
+		JobTemplate workload `yaml:"jobTemplate"`
+	} `yaml:"spec"`
+}
+
+type list struct {
+	Items []*kubernetesObject `yaml:"items"`
+}
+
+// kubernetesObject defers decoding the object until its kind is known, so that unrelated resources (e.g., custom resources) can't cause errors
+type kubernetesObject struct {
+	Kind      string
+	unmarshal func(interface{}) error
+}
+
+func (k *kubernetesObject) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var typeMeta struct {
+		Kind string `yaml:"kind"`
+	}
+
+	if err := unmarshal(&typeMeta); err != nil {
+		return err
+	}
+
+	k.Kind = typeMeta.Kind
+	k.unmarshal = unmarshal
+
+	return nil
+}
+
+func (k *kubernetesObject) podSpecs() ([]podSpec, error) {
+	switch k.Kind {
+	case "Pod":
+		var pod podTemplate
+		err := k.unmarshal(&pod)
+
+		return []podSpec{pod.Spec}, err
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		var w workload
+		err := k.unmarshal(&w)
+
+		return []podSpec{w.Spec.Template.Spec}, err
+	case "CronJob":
+		var c cronJob
+		err := k.unmarshal(&c)
+
+		return []podSpec{c.Spec.JobTemplate.Spec.Template.Spec}, err
+	case "List":
+		var l list
+		if err := k.unmarshal(&l); err != nil {
+			return nil, err
+		}
+
+		var specs []podSpec
+		for _, item := range l.Items {
+			itemSpecs, err := item.podSpecs()
+			if err != nil {
+				return nil, err
+			}
+			specs = append(specs, itemSpecs...)
+		}
+
+		return specs, nil
+	}
+
+	return nil, nil
+}
+
+func parseKubernetesImages(manifest []byte) ([]string, error) {
+	var images []string
+	decoder := yaml.NewDecoder(bytes.NewReader(manifest))
+	for {
+		var object kubernetesObject
+		err := decoder.Decode(&object)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		specs, err := object.podSpecs()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %s", object.Kind, err)
+		}
+
+		for _, spec := range specs {
+			for _, c := range append(spec.InitContainers, spec.Containers...) {
+				if c.Image != "" {
+					images = append(images, c.Image)
+				}
+			}
+		}
+	}
+
+	return images, nil
+}
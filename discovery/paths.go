@@ -0,0 +1,125 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rode/enforcer-action/registry"
+)
+
+// Image is a container image reference declared in a file
+type Image struct {
+	Reference string
+	Source    string
+}
+
+// Pinned is true when the image reference includes a digest
+func (i *Image) Pinned() bool {
+	return strings.Contains(i.Reference, "@sha256:")
+}
+
+// expandPaths resolves a list of files, directories, and glob patterns to the files they match.
+// Directories are searched recursively for files with one of the given extensions.
+func expandPaths(patterns []string, extensions ...string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	addFile := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files matched %q", pattern)
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+
+			if !info.IsDir() {
+				addFile(match)
+				continue
+			}
+
+			err = filepath.WalkDir(match, func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				if !entry.IsDir() && hasExtension(path, extensions) {
+					addFile(path)
+				}
+
+				return nil
+			})
+
+			if err != nil {
+				return nil, fmt.Errorf("error searching directory %s: %s", match, err)
+			}
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func hasExtension(path string, extensions []string) bool {
+	for _, extension := range extensions {
+		if strings.HasSuffix(path, extension) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appendImage adds an image to the list if it hasn't already been seen, comparing the normalized references so that
+// e.g. nginx and docker.io/library/nginx:latest are the same image
+func appendImage(images []*Image, image *Image) []*Image {
+	for _, existing := range images {
+		if normalizeReference(existing.Reference) == normalizeReference(image.Reference) {
+			return images
+		}
+	}
+
+	return append(images, image)
+}
+
+// normalizeReference returns the fully qualified reference, or the reference as-is if it can't be parsed
+func normalizeReference(reference string) string {
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return reference
+	}
+
+	return ref.String()
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import "strings"
+
+// FindPolicyFiles returns the Rego files that match the patterns, which may be files, directories, or globs.
+// Rego unit tests (_test.rego files) are skipped, since they aren't policies.
+func FindPolicyFiles(patterns []string) ([]string, error) {
+	files, err := expandPaths(patterns, ".rego")
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []string
+	for _, file := range files {
+		if !strings.HasSuffix(file, "_test.rego") {
+			policies = append(policies, file)
+		}
+	}
+
+	return policies, nil
+}
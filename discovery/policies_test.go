@@ -0,0 +1,68 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("policies", func() {
+	Context("FindPolicyFiles", func() {
+		var (
+			dir        string
+			signedPath string
+			vulnsPath  string
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "policies")
+			Expect(err).NotTo(HaveOccurred())
+
+			signedPath = writeFile(dir, "images/signed.rego", "package signed")
+			vulnsPath = writeFile(dir, "vulns.rego", "package vulns")
+			writeFile(dir, "images/signed_test.rego", "package signed")
+			writeFile(dir, "README.md", fake.Sentence(5))
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("should search directories for policies, skipping tests", func() {
+			actual, err := FindPolicyFiles([]string{dir})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal([]string{signedPath, vulnsPath}))
+		})
+
+		It("should expand globs", func() {
+			actual, err := FindPolicyFiles([]string{filepath.Join(dir, "*", "*.rego")})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal([]string{signedPath}))
+		})
+
+		It("should return an error when a pattern doesn't match any files", func() {
+			_, err := FindPolicyFiles([]string{filepath.Join(dir, "missing.rego")})
+
+			Expect(err).To(MatchError(ContainSubstring("no files matched")))
+		})
+	})
+})
@@ -0,0 +1,216 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Client calls Rode through its HTTP/JSON gateway, for runners that can't reach Rode over gRPC.
+// It implements the subset of the Rode API used by the action.
+type Client struct {
+	httpClient *http.Client
+	baseUrl    string
+}
+
+type errorResponse struct {
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message"`
+}
+
+func NewClient(httpClient *http.Client, baseUrl string) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+	}
+}
+
+func (c *Client) EvaluateResource(ctx context.Context, in *rode.ResourceEvaluationRequest, _ ...grpc.CallOption) (*rode.ResourceEvaluationResult, error) {
+	out := &rode.ResourceEvaluationResult{}
+
+	return out, c.do(ctx, http.MethodPost, "/v1alpha1/resource-evaluations", in, out)
+}
+
+func (c *Client) GetPolicy(ctx context.Context, in *rode.GetPolicyRequest, _ ...grpc.CallOption) (*rode.Policy, error) {
+	out := &rode.Policy{}
+
+	return out, c.do(ctx, http.MethodGet, "/v1alpha1/policies/"+url.PathEscape(in.Id), nil, out)
+}
+
+func (c *Client) ListVersionedResourceOccurrences(ctx context.Context, in *rode.ListVersionedResourceOccurrencesRequest, _ ...grpc.CallOption) (*rode.ListVersionedResourceOccurrencesResponse, error) {
+	query := url.Values{}
+	query.Set("resourceUri", in.ResourceUri)
+	if in.PageSize != 0 {
+		query.Set("pageSize", strconv.Itoa(int(in.PageSize)))
+	}
+	if in.PageToken != "" {
+		query.Set("pageToken", in.PageToken)
+	}
+	if in.FetchRelatedNotes {
+		query.Set("fetchRelatedNotes", "true")
+	}
+
+	out := &rode.ListVersionedResourceOccurrencesResponse{}
+
+	return out, c.do(ctx, http.MethodGet, "/v1alpha1/versioned-resource-occurrences?"+query.Encode(), nil, out)
+}
+
+func (c *Client) BatchCreateOccurrences(ctx context.Context, in *rode.BatchCreateOccurrencesRequest, _ ...grpc.CallOption) (*rode.BatchCreateOccurrencesResponse, error) {
+	out := &rode.BatchCreateOccurrencesResponse{}
+
+	return out, c.do(ctx, http.MethodPost, "/v1alpha1/occurrences:batchCreate", in, out)
+}
+
+func (c *Client) GetResourceEvaluation(ctx context.Context, in *rode.GetResourceEvaluationRequest, _ ...grpc.CallOption) (*rode.ResourceEvaluationResult, error) {
+	out := &rode.ResourceEvaluationResult{}
+
+	return out, c.do(ctx, http.MethodGet, "/v1alpha1/resource-evaluations/"+url.PathEscape(in.Id), nil, out)
+}
+
+func (c *Client) ListPolicyGroups(ctx context.Context, in *rode.ListPolicyGroupsRequest, _ ...grpc.CallOption) (*rode.ListPolicyGroupsResponse, error) {
+	out := &rode.ListPolicyGroupsResponse{}
+
+	return out, c.do(ctx, http.MethodGet, "/v1alpha1/policy-groups"+pageQuery(in.Filter, in.PageSize, in.PageToken), nil, out)
+}
+
+func (c *Client) GetPolicyGroup(ctx context.Context, in *rode.GetPolicyGroupRequest, _ ...grpc.CallOption) (*rode.PolicyGroup, error) {
+	out := &rode.PolicyGroup{}
+
+	return out, c.do(ctx, http.MethodGet, "/v1alpha1/policy-groups/"+url.PathEscape(in.Name), nil, out)
+}
+
+// ListPolicyAssignments lists the assignments of a policy group, or of a policy when the group isn't set
+func (c *Client) ListPolicyAssignments(ctx context.Context, in *rode.ListPolicyAssignmentsRequest, _ ...grpc.CallOption) (*rode.ListPolicyAssignmentsResponse, error) {
+	path := "/v1alpha1/policies/" + url.PathEscape(in.PolicyId) + "/assignments"
+	if in.PolicyGroup != "" {
+		path = "/v1alpha1/policy-groups/" + url.PathEscape(in.PolicyGroup) + "/assignments"
+	}
+
+	out := &rode.ListPolicyAssignmentsResponse{}
+
+	return out, c.do(ctx, http.MethodGet, path+pageQuery(in.Filter, in.PageSize, in.PageToken), nil, out)
+}
+
+// ValidatePolicy compiles Rego without saving it as a policy
+func (c *Client) ValidatePolicy(ctx context.Context, in *rode.ValidatePolicyRequest, _ ...grpc.CallOption) (*rode.ValidatePolicyResponse, error) {
+	out := &rode.ValidatePolicyResponse{}
+
+	return out, c.do(ctx, http.MethodPost, "/v1alpha1/policies:validate", in, out)
+}
+
+func pageQuery(filter string, pageSize int32, pageToken string) string {
+	query := url.Values{}
+	if filter != "" {
+		query.Set("filter", filter)
+	}
+	if pageSize != 0 {
+		query.Set("pageSize", strconv.Itoa(int(pageSize)))
+	}
+	if pageToken != "" {
+		query.Set("pageToken", pageToken)
+	}
+
+	if len(query) == 0 {
+		return ""
+	}
+
+	return "?" + query.Encode()
+}
+
+// do sends the request body, if there is one, and decodes the response into out. Errors returned by the gateway
+// are converted back into gRPC status errors, so that they look the same as errors from the gRPC client.
+func (c *Client) do(ctx context.Context, method, path string, in, out proto.Message) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := protojson.Marshal(in)
+		if err != nil {
+			return status.Errorf(codes.Internal, "error encoding request: %s", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.baseUrl+path, body)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "error creating request: %s", err)
+	}
+	request.Header.Set("Accept", "application/json")
+	if in != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "error calling Rode gateway: %s", err)
+	}
+	defer response.Body.Close()
+
+	payload, err := io.ReadAll(response.Body)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "error reading response from Rode gateway: %s", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var gatewayError errorResponse
+		if err := json.Unmarshal(payload, &gatewayError); err != nil || gatewayError.Code == codes.OK {
+			return status.Errorf(codes.Unknown, "unexpected status %d from Rode gateway: %s", response.StatusCode, truncate(string(payload)))
+		}
+
+		return status.Error(gatewayError.Code, gatewayError.Message)
+	}
+
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(payload, out); err != nil {
+		return status.Errorf(codes.Internal, "error decoding response from Rode gateway: %s", err)
+	}
+
+	return nil
+}
+
+func truncate(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 200 {
+		return value[:200] + "..."
+	}
+
+	return value
+}
+
+// NewTransport returns an HTTP transport for the gateway that uses the proxy from the environment (HTTPS_PROXY and NO_PROXY)
+// and, when it's set, the TLS configuration of the Rode connection
+func NewTransport(tlsConfig *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig.Clone()
+		// gRPC only offers HTTP/2, but proxies in front of the gateway may require HTTP/1.1
+		transport.TLSClientConfig.NextProtos = nil
+	}
+
+	return transport
+}
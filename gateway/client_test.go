@@ -0,0 +1,327 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rode/enforcer-action/action"
+	rode "github.com/rode/rode/proto/v1alpha1"
+	"github.com/rode/rode/proto/v1alpha1fakes"
+	grafeas_common_proto "github.com/rode/rode/protodeps/grafeas/proto/v1beta1/common_go_proto"
+	"github.com/rode/rode/protodeps/grafeas/proto/v1beta1/grafeas_go_proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ action.RodeClient = &Client{}
+
+var _ = Describe("Client", func() {
+	var (
+		ctx        = context.Background()
+		server     *httptest.Server
+		rodeClient *v1alpha1fakes.FakeRodeClient
+		client     *Client
+	)
+
+	BeforeEach(func() {
+		rodeClient = &v1alpha1fakes.FakeRodeClient{}
+
+		// serve the client through the generated Rode gateway, so that requests are routed and decoded as they would be by Rode
+		mux := runtime.NewServeMux()
+		Expect(rode.RegisterRodeHandlerClient(ctx, mux, rodeClient)).To(Succeed())
+		server = httptest.NewServer(mux)
+
+		client = NewClient(server.Client(), server.URL+"/")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("EvaluateResource", func() {
+		It("should evaluate the resource through the gateway", func() {
+			request := &rode.ResourceEvaluationRequest{
+				PolicyGroup: fake.Word(),
+				ResourceUri: fake.URL(),
+				Source: &rode.ResourceEvaluationSource{
+					Name: "enforcer-action",
+					Url:  fake.URL(),
+				},
+			}
+			expected := &rode.ResourceEvaluationResult{
+				ResourceEvaluation: &rode.ResourceEvaluation{
+					Id:   fake.UUID(),
+					Pass: true,
+					ResourceVersion: &rode.ResourceVersion{
+						Version: request.ResourceUri,
+					},
+				},
+			}
+			rodeClient.EvaluateResourceReturns(expected, nil)
+
+			actual, err := client.EvaluateResource(ctx, request)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(actual, expected)).To(BeTrue())
+			Expect(rodeClient.EvaluateResourceCallCount()).To(Equal(1))
+			_, actualRequest, _ := rodeClient.EvaluateResourceArgsForCall(0)
+			Expect(proto.Equal(actualRequest, request)).To(BeTrue())
+		})
+	})
+
+	Describe("GetPolicy", func() {
+		It("should get the policy by id", func() {
+			policyId := fake.UUID()
+			expected := &rode.Policy{
+				Id:             policyId,
+				Name:           fake.Word(),
+				CurrentVersion: 2,
+			}
+			rodeClient.GetPolicyReturns(expected, nil)
+
+			actual, err := client.GetPolicy(ctx, &rode.GetPolicyRequest{Id: policyId})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(actual, expected)).To(BeTrue())
+			_, actualRequest, _ := rodeClient.GetPolicyArgsForCall(0)
+			Expect(actualRequest.Id).To(Equal(policyId))
+		})
+
+		It("should return the status of gateway errors", func() {
+			message := fake.Sentence(5)
+			rodeClient.GetPolicyReturns(nil, status.Error(codes.NotFound, message))
+
+			_, err := client.GetPolicy(ctx, &rode.GetPolicyRequest{Id: fake.UUID()})
+
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+			Expect(status.Convert(err).Message()).To(Equal(message))
+		})
+	})
+
+	Describe("ListVersionedResourceOccurrences", func() {
+		It("should pass the request as query parameters", func() {
+			request := &rode.ListVersionedResourceOccurrencesRequest{
+				ResourceUri:       fake.URL(),
+				PageSize:          1000,
+				PageToken:         fake.LetterN(10),
+				FetchRelatedNotes: true,
+			}
+			expected := &rode.ListVersionedResourceOccurrencesResponse{
+				Occurrences: []*grafeas_go_proto.Occurrence{
+					{
+						Kind:     grafeas_common_proto.NoteKind_VULNERABILITY,
+						NoteName: "projects/rode/notes/harbor-scan",
+					},
+				},
+				NextPageToken: fake.LetterN(10),
+			}
+			rodeClient.ListVersionedResourceOccurrencesReturns(expected, nil)
+
+			actual, err := client.ListVersionedResourceOccurrences(ctx, request)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(actual, expected)).To(BeTrue())
+			_, actualRequest, _ := rodeClient.ListVersionedResourceOccurrencesArgsForCall(0)
+			Expect(proto.Equal(actualRequest, request)).To(BeTrue())
+		})
+	})
+
+	Describe("BatchCreateOccurrences", func() {
+		It("should create the occurrences", func() {
+			request := &rode.BatchCreateOccurrencesRequest{
+				Occurrences: []*grafeas_go_proto.Occurrence{
+					{
+						Resource: &grafeas_go_proto.Resource{Uri: fake.URL()},
+						NoteName: "projects/rode/notes/enforcer-action-decision",
+						Kind:     grafeas_common_proto.NoteKind_ATTESTATION,
+					},
+				},
+			}
+			rodeClient.BatchCreateOccurrencesReturns(&rode.BatchCreateOccurrencesResponse{Occurrences: request.Occurrences}, nil)
+
+			_, err := client.BatchCreateOccurrences(ctx, request)
+
+			Expect(err).NotTo(HaveOccurred())
+			_, actualRequest, _ := rodeClient.BatchCreateOccurrencesArgsForCall(0)
+			Expect(proto.Equal(actualRequest, request)).To(BeTrue())
+		})
+	})
+
+	Describe("GetResourceEvaluation", func() {
+		It("should get the evaluation by id", func() {
+			evaluationId := fake.UUID()
+			expected := &rode.ResourceEvaluationResult{
+				ResourceEvaluation: &rode.ResourceEvaluation{
+					Id:          evaluationId,
+					PolicyGroup: fake.Word(),
+					ResourceVersion: &rode.ResourceVersion{
+						Version: fake.URL(),
+					},
+				},
+				PolicyEvaluations: []*rode.PolicyEvaluation{
+					{PolicyVersionId: fake.UUID(), Pass: true},
+				},
+			}
+			rodeClient.GetResourceEvaluationReturns(expected, nil)
+
+			actual, err := client.GetResourceEvaluation(ctx, &rode.GetResourceEvaluationRequest{Id: evaluationId})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(actual, expected)).To(BeTrue())
+			_, actualRequest, _ := rodeClient.GetResourceEvaluationArgsForCall(0)
+			Expect(actualRequest.Id).To(Equal(evaluationId))
+		})
+	})
+
+	Describe("ListPolicyGroups", func() {
+		It("should pass the request as query parameters", func() {
+			request := &rode.ListPolicyGroupsRequest{
+				Filter:    `name.startsWith("prod")`,
+				PageSize:  50,
+				PageToken: fake.LetterN(10),
+			}
+			expected := &rode.ListPolicyGroupsResponse{
+				PolicyGroups: []*rode.PolicyGroup{
+					{Name: fake.Word(), Description: fake.Sentence(3)},
+				},
+			}
+			rodeClient.ListPolicyGroupsReturns(expected, nil)
+
+			actual, err := client.ListPolicyGroups(ctx, request)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(actual, expected)).To(BeTrue())
+			_, actualRequest, _ := rodeClient.ListPolicyGroupsArgsForCall(0)
+			Expect(proto.Equal(actualRequest, request)).To(BeTrue())
+		})
+	})
+
+	Describe("GetPolicyGroup", func() {
+		It("should get the policy group by name", func() {
+			expected := &rode.PolicyGroup{Name: fake.Word(), Description: fake.Sentence(3)}
+			rodeClient.GetPolicyGroupReturns(expected, nil)
+
+			actual, err := client.GetPolicyGroup(ctx, &rode.GetPolicyGroupRequest{Name: expected.Name})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(actual, expected)).To(BeTrue())
+			_, actualRequest, _ := rodeClient.GetPolicyGroupArgsForCall(0)
+			Expect(actualRequest.Name).To(Equal(expected.Name))
+		})
+
+		It("should return not found errors", func() {
+			rodeClient.GetPolicyGroupReturns(nil, status.Error(codes.NotFound, "policy group not found"))
+
+			_, err := client.GetPolicyGroup(ctx, &rode.GetPolicyGroupRequest{Name: fake.Word()})
+
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+	})
+
+	Describe("ValidatePolicy", func() {
+		It("should send the Rego to be validated", func() {
+			request := &rode.ValidatePolicyRequest{Policy: "package " + fake.Word()}
+			expected := &rode.ValidatePolicyResponse{Policy: request.Policy, Compile: false, Errors: []string{fake.Sentence(3)}}
+			rodeClient.ValidatePolicyReturns(expected, nil)
+
+			actual, err := client.ValidatePolicy(ctx, request)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(actual, expected)).To(BeTrue())
+			_, actualRequest, _ := rodeClient.ValidatePolicyArgsForCall(0)
+			Expect(actualRequest.Policy).To(Equal(request.Policy))
+		})
+	})
+
+	Describe("ListPolicyAssignments", func() {
+		var expected *rode.ListPolicyAssignmentsResponse
+
+		BeforeEach(func() {
+			expected = &rode.ListPolicyAssignmentsResponse{
+				PolicyAssignments: []*rode.PolicyAssignment{
+					{Id: fake.UUID(), PolicyVersionId: fake.UUID(), PolicyGroup: fake.Word()},
+				},
+			}
+			rodeClient.ListPolicyAssignmentsReturns(expected, nil)
+		})
+
+		It("should list the assignments of a policy group", func() {
+			request := &rode.ListPolicyAssignmentsRequest{
+				PolicyGroup: fake.Word(),
+				PageToken:   fake.LetterN(10),
+			}
+
+			actual, err := client.ListPolicyAssignments(ctx, request)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(actual, expected)).To(BeTrue())
+			_, actualRequest, _ := rodeClient.ListPolicyAssignmentsArgsForCall(0)
+			Expect(proto.Equal(actualRequest, request)).To(BeTrue())
+		})
+
+		It("should list the assignments of a policy", func() {
+			request := &rode.ListPolicyAssignmentsRequest{
+				PolicyId: fake.UUID(),
+			}
+
+			_, err := client.ListPolicyAssignments(ctx, request)
+
+			Expect(err).NotTo(HaveOccurred())
+			_, actualRequest, _ := rodeClient.ListPolicyAssignmentsArgsForCall(0)
+			Expect(proto.Equal(actualRequest, request)).To(BeTrue())
+		})
+	})
+
+	When("the response isn't from the gateway", func() {
+		BeforeEach(func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+				fmt.Fprint(w, "<html>proxy error</html>")
+			})
+		})
+
+		It("should return an error with the response status", func() {
+			_, err := client.GetPolicy(ctx, &rode.GetPolicyRequest{Id: fake.UUID()})
+
+			Expect(status.Code(err)).To(Equal(codes.Unknown))
+			Expect(err).To(MatchError(ContainSubstring("unexpected status 502 from Rode gateway: <html>proxy error</html>")))
+		})
+	})
+
+	Describe("NewTransport", func() {
+		It("should use the proxy from the environment", func() {
+			Expect(NewTransport(nil).Proxy).NotTo(BeNil())
+		})
+
+		It("should allow HTTP/1.1 with the Rode TLS configuration", func() {
+			tlsConfig := &tls.Config{ServerName: fake.DomainName(), NextProtos: []string{"h2"}}
+
+			transport := NewTransport(tlsConfig)
+
+			Expect(transport.TLSClientConfig.ServerName).To(Equal(tlsConfig.ServerName))
+			Expect(transport.TLSClientConfig.NextProtos).To(BeEmpty())
+			Expect(tlsConfig.NextProtos).To(ConsistOf("h2"))
+		})
+	})
+})
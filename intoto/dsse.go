@@ -0,0 +1,174 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intoto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Signer signs statements into DSSE envelopes with a local private key, so no network access is needed
+type Signer struct {
+	key   crypto.Signer
+	keyId string
+}
+
+// NewSigner parses a PEM encoded ECDSA, Ed25519 or RSA private key. If keyId is empty, the sha256 digest of the public key is used.
+func NewSigner(keyPem []byte, keyId string) (*Signer, error) {
+	block, _ := pem.Decode(keyPem)
+	if block == nil {
+		return nil, errors.New("signing key isn't PEM encoded")
+	}
+
+	key, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyId == "" {
+		keyId, err = publicKeyId(key.Public())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Signer{key: key, keyId: keyId}, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing signing key: %s", err)
+		}
+
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported signing key type %T", key)
+		}
+
+		return signer, nil
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block type %q for signing key", block.Type)
+}
+
+func publicKeyId(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("error encoding public key: %s", err)
+	}
+	digest := sha256.Sum256(der)
+
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// Sign serializes the statement and wraps it in a signed DSSE envelope
+func (s *Signer) Sign(statement *Statement) (*Envelope, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding statement: %s", err)
+	}
+
+	message := pae(PayloadType, payload)
+	var signature []byte
+	switch s.key.(type) {
+	case ed25519.PrivateKey:
+		signature, err = s.key.Sign(rand.Reader, message, crypto.Hash(0))
+	default:
+		digest := sha256.Sum256(message)
+		signature, err = s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error signing statement: %s", err)
+	}
+
+	return &Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []*Signature{
+			{
+				KeyId: s.keyId,
+				Sig:   base64.StdEncoding.EncodeToString(signature),
+			},
+		},
+	}, nil
+}
+
+// ParsePublicKey parses a PEM encoded PKIX public key, which is used to verify envelopes
+func ParsePublicKey(keyPem []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(keyPem)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("public key isn't a PEM encoded PUBLIC KEY")
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// Verify checks that one of the envelope's signatures was made by the public key
+func (e *Envelope) Verify(publicKey crypto.PublicKey) error {
+	payload, err := e.DecodePayload()
+	if err != nil {
+		return err
+	}
+
+	message := pae(e.PayloadType, payload)
+	digest := sha256.Sum256(message)
+	for _, signature := range e.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err != nil {
+			continue
+		}
+
+		var verified bool
+		switch key := publicKey.(type) {
+		case *ecdsa.PublicKey:
+			verified = ecdsa.VerifyASN1(key, digest[:], sig)
+		case ed25519.PublicKey:
+			verified = ed25519.Verify(key, message, sig)
+		case *rsa.PublicKey:
+			verified = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+		default:
+			return fmt.Errorf("unsupported public key type %T", publicKey)
+		}
+
+		if verified {
+			return nil
+		}
+	}
+
+	return errors.New("no signature in the envelope was made by the public key")
+}
+
+// pae is the DSSE pre-authentication encoding, which is what's actually signed
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
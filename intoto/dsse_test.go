@@ -0,0 +1,135 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intoto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DSSE", func() {
+	var statement *Statement
+
+	BeforeEach(func() {
+		statement = &Statement{
+			Type:          StatementTypeV1,
+			PredicateType: VerificationSummaryV1,
+			Subject:       []*Subject{{Name: fake.LetterN(10), Digest: map[string]string{"sha256": fake.Regex("[a-f0-9]{64}")}}},
+			Predicate:     json.RawMessage(`{"verificationResult":"PASSED"}`),
+		}
+	})
+
+	DescribeTable("signing and verifying", func(generateKey func() (crypto.Signer, *pem.Block)) {
+		key, block := generateKey()
+		signer, err := NewSigner(pem.EncodeToMemory(block), "")
+		Expect(err).NotTo(HaveOccurred())
+
+		envelope, err := signer.Sign(statement)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(envelope.PayloadType).To(Equal(PayloadType))
+		Expect(envelope.Signatures).To(HaveLen(1))
+		expectedKeyId, _ := publicKeyId(key.Public())
+		Expect(envelope.Signatures[0].KeyId).To(Equal(expectedKeyId))
+
+		publicKeyDer, err := x509.MarshalPKIXPublicKey(key.Public())
+		Expect(err).NotTo(HaveOccurred())
+		publicKey, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDer}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(envelope.Verify(publicKey)).To(Succeed())
+
+		payload, err := envelope.DecodePayload()
+		Expect(err).NotTo(HaveOccurred())
+		actual, err := ParseStatement(payload)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual.Subject).To(Equal(statement.Subject))
+
+		By("rejecting a modified payload")
+		statement.Subject[0].Name = fake.LetterN(11)
+		tampered, _ := json.Marshal(statement)
+		envelope.Payload = base64.StdEncoding.EncodeToString(tampered)
+		Expect(envelope.Verify(publicKey)).NotTo(Succeed())
+	},
+		Entry("ECDSA", func() (crypto.Signer, *pem.Block) {
+			key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			der, _ := x509.MarshalECPrivateKey(key)
+
+			return key, &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+		}),
+		Entry("Ed25519", func() (crypto.Signer, *pem.Block) {
+			_, key, _ := ed25519.GenerateKey(rand.Reader)
+			der, _ := x509.MarshalPKCS8PrivateKey(key)
+
+			return key, &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		}),
+		Entry("RSA", func() (crypto.Signer, *pem.Block) {
+			key, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+			return key, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+		}),
+	)
+
+	It("should use the configured key id", func() {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		der, _ := x509.MarshalECPrivateKey(key)
+		expectedKeyId := fake.LetterN(10)
+		signer, err := NewSigner(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), expectedKeyId)
+		Expect(err).NotTo(HaveOccurred())
+
+		envelope, err := signer.Sign(statement)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(envelope.Signatures[0].KeyId).To(Equal(expectedKeyId))
+	})
+
+	It("should not verify a signature from a different key", func() {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		der, _ := x509.MarshalECPrivateKey(key)
+		signer, _ := NewSigner(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), "")
+		envelope, _ := signer.Sign(statement)
+
+		otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+		Expect(envelope.Verify(otherKey.Public())).To(MatchError(ContainSubstring("no signature")))
+	})
+
+	It("should return an error for a key that isn't PEM encoded", func() {
+		_, err := NewSigner([]byte(fake.LetterN(10)), "")
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error for an unsupported PEM block", func() {
+		_, err := NewSigner(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte(fake.LetterN(10))}), "")
+
+		Expect(err).To(MatchError(ContainSubstring("unsupported PEM block type")))
+	})
+
+	It("should encode the payload type and payload for signing", func() {
+		Expect(string(pae("text/plain", []byte("hello world")))).To(Equal("DSSEv1 10 text/plain 11 hello world"))
+	})
+})
@@ -0,0 +1,173 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intoto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	StatementType      = "https://in-toto.io/Statement/v0.1"
+	StatementTypeV1    = "https://in-toto.io/Statement/v1"
+	PayloadType        = "application/vnd.in-toto+json"
+	SlsaProvenanceV01  = "https://slsa.dev/provenance/v0.1"
+	SlsaProvenanceV02  = "https://slsa.dev/provenance/v0.2"
+	sha256DigestPrefix = "sha256:"
+)
+
+// Subject is an artifact that a statement is about, identified by its digests
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Statement is an in-toto attestation statement
+type Statement struct {
+	Type          string          `json:"_type"`
+	PredicateType string          `json:"predicateType"`
+	Subject       []*Subject      `json:"subject"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+type Signature struct {
+	KeyId string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Envelope is a DSSE envelope, which is how in-toto statements are usually signed
+type Envelope struct {
+	PayloadType string       `json:"payloadType"`
+	Payload     string       `json:"payload"`
+	Signatures  []*Signature `json:"signatures"`
+}
+
+type SlsaMaterial struct {
+	Uri    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// SlsaProvenance holds the parts of a SLSA v0.1 or v0.2 provenance predicate that map to a Grafeas build occurrence
+type SlsaProvenance struct {
+	Builder struct {
+		Id string `json:"id"`
+	} `json:"builder"`
+	BuildType string `json:"buildType"`
+	Metadata  struct {
+		BuildInvocationId string     `json:"buildInvocationId"`
+		BuildStartedOn    *time.Time `json:"buildStartedOn"`
+		BuildFinishedOn   *time.Time `json:"buildFinishedOn"`
+	} `json:"metadata"`
+	Materials []*SlsaMaterial `json:"materials"`
+}
+
+// ParseStatement parses an in-toto statement, unwrapping it from a DSSE envelope if necessary. Signatures aren't verified.
+func ParseStatement(data []byte) (*Statement, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("error parsing in-toto statement: %s", err)
+	}
+
+	if _, ok := fields["payloadType"]; ok {
+		envelope := &Envelope{}
+		if err := json.Unmarshal(data, envelope); err != nil {
+			return nil, fmt.Errorf("error parsing DSSE envelope: %s", err)
+		}
+
+		payload, err := envelope.DecodePayload()
+		if err != nil {
+			return nil, err
+		}
+
+		return ParseStatement(payload)
+	}
+
+	statement := &Statement{}
+	if err := json.Unmarshal(data, statement); err != nil {
+		return nil, fmt.Errorf("error parsing in-toto statement: %s", err)
+	}
+
+	if statement.Type != StatementType && statement.Type != StatementTypeV1 {
+		return nil, fmt.Errorf("unsupported statement type %q, expected %s or %s", statement.Type, StatementType, StatementTypeV1)
+	}
+
+	return statement, nil
+}
+
+// DecodePayload returns the statement wrapped by the envelope
+func (e *Envelope) DecodePayload() ([]byte, error) {
+	if e.PayloadType != PayloadType {
+		return nil, fmt.Errorf("unsupported DSSE payload type %q, expected %s", e.PayloadType, PayloadType)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding DSSE payload: %s", err)
+	}
+
+	return payload, nil
+}
+
+// SlsaProvenance returns the predicate when the statement is SLSA provenance
+func (s *Statement) SlsaProvenance() (*SlsaProvenance, error) {
+	if s.PredicateType != SlsaProvenanceV01 && s.PredicateType != SlsaProvenanceV02 {
+		return nil, fmt.Errorf("unsupported predicate type %q, expected SLSA provenance", s.PredicateType)
+	}
+
+	provenance := &SlsaProvenance{}
+	if err := json.Unmarshal(s.Predicate, provenance); err != nil {
+		return nil, fmt.Errorf("error parsing SLSA provenance: %s", err)
+	}
+
+	return provenance, nil
+}
+
+// HasSubjectDigest returns true if one of the subjects has the given digest, in the form sha256:<hex>
+func (s *Statement) HasSubjectDigest(digest string) bool {
+	algorithm, value, ok := splitDigest(digest)
+	if !ok {
+		return false
+	}
+
+	for _, subject := range s.Subject {
+		if strings.EqualFold(subject.Digest[algorithm], value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitDigest(digest string) (string, string, bool) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// Sha256Digest returns the subject's digest in the form sha256:<hex>, if it has one
+func (s *Subject) Sha256Digest() (string, bool) {
+	value, ok := s.Digest["sha256"]
+	if !ok {
+		return "", false
+	}
+
+	return sha256DigestPrefix + value, true
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intoto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Statement", func() {
+	var (
+		digest    string
+		statement string
+	)
+
+	BeforeEach(func() {
+		digest = fake.Regex("[a-f0-9]{64}")
+		statement = fmt.Sprintf(`{
+			"_type": "https://in-toto.io/Statement/v0.1",
+			"predicateType": "https://slsa.dev/provenance/v0.2",
+			"subject": [{"name": "ghcr.io/rode/app", "digest": {"sha256": "%s"}}],
+			"predicate": {
+				"builder": {"id": "https://github.com/rode/builder@v1"},
+				"buildType": "https://github.com/Attestations/GitHubActionsWorkflow@v1",
+				"metadata": {
+					"buildInvocationId": "1234-1",
+					"buildStartedOn": "2021-08-01T10:00:00Z",
+					"buildFinishedOn": "2021-08-01T10:05:00Z"
+				},
+				"materials": [{"uri": "git+https://github.com/rode/app@refs/heads/main", "digest": {"sha1": "abc123"}}]
+			}
+		}`, digest)
+	})
+
+	Describe("ParseStatement", func() {
+		It("should parse the statement", func() {
+			actual, err := ParseStatement([]byte(statement))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual.PredicateType).To(Equal(SlsaProvenanceV02))
+			Expect(actual.Subject).To(Equal([]*Subject{{Name: "ghcr.io/rode/app", Digest: map[string]string{"sha256": digest}}}))
+		})
+
+		It("should unwrap a DSSE envelope", func() {
+			envelope := fmt.Sprintf(`{"payloadType": "%s", "payload": "%s", "signatures": [{"keyid": "", "sig": "%s"}]}`,
+				PayloadType, base64.StdEncoding.EncodeToString([]byte(statement)), fake.LetterN(10))
+
+			actual, err := ParseStatement([]byte(envelope))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual.PredicateType).To(Equal(SlsaProvenanceV02))
+		})
+
+		It("should return an error for other DSSE payload types", func() {
+			envelope := fmt.Sprintf(`{"payloadType": "text/plain", "payload": "%s"}`, base64.StdEncoding.EncodeToString([]byte(statement)))
+
+			_, err := ParseStatement([]byte(envelope))
+
+			Expect(err).To(MatchError(ContainSubstring("unsupported DSSE payload type")))
+		})
+
+		It("should return an error for other statement types", func() {
+			_, err := ParseStatement([]byte(`{"_type": "https://example.com/Statement"}`))
+
+			Expect(err).To(MatchError(ContainSubstring("unsupported statement type")))
+		})
+
+		It("should return an error for invalid json", func() {
+			_, err := ParseStatement([]byte(fake.LetterN(10)))
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("SlsaProvenance", func() {
+		It("should parse the predicate", func() {
+			parsed, err := ParseStatement([]byte(statement))
+			Expect(err).NotTo(HaveOccurred())
+
+			actual, err := parsed.SlsaProvenance()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual.Builder.Id).To(Equal("https://github.com/rode/builder@v1"))
+			Expect(actual.Metadata.BuildInvocationId).To(Equal("1234-1"))
+			Expect(*actual.Metadata.BuildStartedOn).To(Equal(time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)))
+			Expect(actual.Materials).To(HaveLen(1))
+		})
+
+		It("should return an error for other predicates", func() {
+			parsed := &Statement{PredicateType: "https://example.com/predicate"}
+
+			_, err := parsed.SlsaProvenance()
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("HasSubjectDigest", func() {
+		It("should match a subject digest", func() {
+			parsed, _ := ParseStatement([]byte(statement))
+
+			Expect(parsed.HasSubjectDigest("sha256:" + digest)).To(BeTrue())
+			Expect(parsed.HasSubjectDigest("sha256:" + fake.Regex("[a-f0-9]{64}"))).To(BeFalse())
+			Expect(parsed.HasSubjectDigest(digest)).To(BeFalse())
+		})
+	})
+})
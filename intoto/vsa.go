@@ -0,0 +1,62 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intoto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const VerificationSummaryV1 = "https://slsa.dev/verification_summary/v1"
+
+const (
+	VerificationPassed = "PASSED"
+	VerificationFailed = "FAILED"
+)
+
+// Verifier identifies the verifier, and maps the names of its components to their versions
+type Verifier struct {
+	Id      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type VerificationPolicy struct {
+	Uri string `json:"uri"`
+}
+
+// VerificationSummary is a SLSA Verification Summary Attestation (VSA) predicate, which records that a verifier checked an artifact against a policy
+type VerificationSummary struct {
+	Verifier           *Verifier           `json:"verifier"`
+	TimeVerified       time.Time           `json:"timeVerified"`
+	ResourceUri        string              `json:"resourceUri"`
+	Policy             *VerificationPolicy `json:"policy"`
+	VerificationResult string              `json:"verificationResult"`
+	VerifiedLevels     []string            `json:"verifiedLevels"`
+}
+
+// NewVerificationSummaryStatement creates a statement with a VSA predicate about a single subject
+func NewVerificationSummaryStatement(subject *Subject, summary *VerificationSummary) (*Statement, error) {
+	predicate, err := json.Marshal(summary)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Statement{
+		Type:          StatementTypeV1,
+		PredicateType: VerificationSummaryV1,
+		Subject:       []*Subject{subject},
+		Predicate:     predicate,
+	}, nil
+}
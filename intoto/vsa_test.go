@@ -0,0 +1,81 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intoto
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Verification summary", func() {
+	var (
+		subject *Subject
+		summary *VerificationSummary
+	)
+
+	BeforeEach(func() {
+		subject = &Subject{Name: fake.LetterN(10), Digest: map[string]string{"sha256": fake.Regex("[a-f0-9]{64}")}}
+		summary = &VerificationSummary{
+			Verifier:           &Verifier{Id: "https://github.com/rode/enforcer-action", Version: map[string]string{"enforcer-action": "v0.4.0"}},
+			TimeVerified:       time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC),
+			ResourceUri:        "harbor.localhost/rode-demo/app@sha256:abc123",
+			Policy:             &VerificationPolicy{Uri: "rode://rode:50051/policy-groups/prod"},
+			VerificationResult: VerificationPassed,
+			VerifiedLevels:     []string{"SLSA_BUILD_LEVEL_3"},
+		}
+	})
+
+	It("should create a statement with the VSA predicate", func() {
+		statement, err := NewVerificationSummaryStatement(subject, summary)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statement.Type).To(Equal(StatementTypeV1))
+		Expect(statement.PredicateType).To(Equal(VerificationSummaryV1))
+		Expect(statement.Subject).To(ConsistOf(subject))
+
+		actualSummary := &VerificationSummary{}
+		Expect(json.Unmarshal(statement.Predicate, actualSummary)).To(Succeed())
+		Expect(actualSummary).To(Equal(summary))
+	})
+
+	It("should serialize the statement with the v1 field names", func() {
+		subject = &Subject{Name: "harbor.localhost/rode-demo/app", Digest: map[string]string{"sha256": "abc123"}}
+		statement, err := NewVerificationSummaryStatement(subject, summary)
+		Expect(err).NotTo(HaveOccurred())
+
+		actual, err := json.Marshal(statement)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual).To(MatchJSON(`{
+			"_type": "https://in-toto.io/Statement/v1",
+			"predicateType": "https://slsa.dev/verification_summary/v1",
+			"subject": [{"name": "harbor.localhost/rode-demo/app", "digest": {"sha256": "abc123"}}],
+			"predicate": {
+				"verifier": {
+					"id": "https://github.com/rode/enforcer-action",
+					"version": {"enforcer-action": "v0.4.0"}
+				},
+				"timeVerified": "2021-08-01T10:00:00Z",
+				"resourceUri": "harbor.localhost/rode-demo/app@sha256:abc123",
+				"policy": {"uri": "rode://rode:50051/policy-groups/prod"},
+				"verificationResult": "PASSED",
+				"verifiedLevels": ["SLSA_BUILD_LEVEL_3"]
+			}
+		}`))
+	})
+})
@@ -16,19 +16,32 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v35/github"
 	"github.com/rode/enforcer-action/action"
+	"github.com/rode/enforcer-action/auth"
 	"github.com/rode/enforcer-action/config"
+	"github.com/rode/enforcer-action/gateway"
+	"github.com/rode/enforcer-action/registry"
 	"github.com/rode/rode/common"
+	"github.com/rode/rode/proto/v1alpha1"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+// rodeDialTimeout matches the timeout common uses to connect to Rode
+var rodeDialTimeout = 10 * time.Second
+
 func newLogger() (*zap.Logger, error) {
 	c := zap.NewDevelopmentConfig()
 	c.DisableCaller = true
@@ -38,8 +51,23 @@ func newLogger() (*zap.Logger, error) {
 	return c.Build()
 }
 
-func setOutputVariable(name string, value interface{}) {
-	fmt.Printf("\n::set-output name=%s::%v\n", name, value)
+// setOutputVariable writes a step output to the file in GITHUB_OUTPUT, so that stdout only contains the result.
+// Runners that predate the output file still read the set-output command from stderr.
+func setOutputVariable(logger *zap.Logger, c *config.GitHubConfig, name string, value interface{}) {
+	if c.OutputFile == "" {
+		fmt.Fprintf(os.Stderr, "\n::set-output name=%s::%v\n", name, value)
+		return
+	}
+
+	file, err := os.OpenFile(c.OutputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Fatal("error opening GitHub output file", zap.Error(err))
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "%s=%v\n", name, value); err != nil {
+		logger.Fatal("error writing output variable", zap.String("name", name), zap.Error(err))
+	}
 }
 
 func fatal(message string) {
@@ -47,19 +75,121 @@ func fatal(message string) {
 	os.Exit(1)
 }
 
-type staticCredential struct {
-	token                    string
-	requireTransportSecurity bool
+// newRodeTokenSource returns the source of bearer tokens for requests to Rode, or nil if none are configured.
+// Over gRPC, common requests client credentials tokens itself, so they're only turned into a token source for the gateway
+// and for connections with custom TLS settings, which common doesn't support.
+func newRodeTokenSource(c *config.Config) oauth2.TokenSource {
+	if c.AccessToken != "" {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: c.AccessToken})
+	}
+
+	if c.ClientConfig.OIDCAuth.ClientID != "" {
+		if c.GatewayUrl == "" && !c.Tls.IsConfigured() {
+			return nil
+		}
+
+		oidc := c.ClientConfig.OIDCAuth
+		client := http.DefaultClient
+		if oidc.TlsInsecureSkipVerify {
+			client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		}
+
+		return auth.NewClientCredentialsTokenSource(client, oidc.ClientID, oidc.ClientSecret, oidc.TokenURL, strings.Fields(oidc.Scopes))
+	}
+
+	if !c.GitHubOidc.Enabled {
+		return nil
+	}
+
+	tokenSource := auth.NewGitHubIdTokenSource(http.DefaultClient, c.GitHubOidc.RequestUrl, c.GitHubOidc.RequestToken, c.GitHubOidc.Audience)
+	if c.GitHubOidc.TokenExchangeUrl != "" {
+		tokenSource = auth.NewExchangeTokenSource(http.DefaultClient, tokenSource, c.GitHubOidc.TokenExchangeUrl, c.GitHubOidc.ClientId, c.GitHubOidc.Scopes)
+	}
+
+	return tokenSource
 }
 
-func (s *staticCredential) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
-	return map[string]string{
-		"authorization": "Bearer " + s.token,
-	}, nil
+// newRodeClient connects to Rode over gRPC, or through the HTTP gateway when rode-gateway-url is set
+func newRodeClient(c *config.Config) (action.RodeClient, error) {
+	var tlsConfig *tls.Config
+	if c.Tls.IsConfigured() {
+		var err error
+		if tlsConfig, err = auth.NewTLSConfig(c.Tls.CaBundle, c.Tls.ClientCert, c.Tls.ClientKey, c.Tls.ServerName); err != nil {
+			return nil, fmt.Errorf("error configuring TLS: %s", err)
+		}
+	}
+
+	tokenSource := newRodeTokenSource(c)
+	if tokenSource != nil {
+		// fetch a token up front so that a misconfigured token request fails before anything is evaluated
+		if _, err := tokenSource.Token(); err != nil {
+			return nil, fmt.Errorf("error getting a token for Rode: %s", err)
+		}
+	}
+
+	if c.GatewayUrl != "" {
+		var transport http.RoundTripper = gateway.NewTransport(tlsConfig)
+		if tokenSource != nil {
+			transport = &oauth2.Transport{Source: tokenSource, Base: transport}
+		}
+
+		return gateway.NewClient(&http.Client{Transport: transport}, c.GatewayUrl), nil
+	}
+
+	if tlsConfig != nil {
+		// common always dials with the default TLS configuration, so connections that need a CA bundle or client
+		// certificate are made here
+		return dialRode(c, tlsConfig, tokenSource)
+	}
+
+	var dialOptions []grpc.DialOption
+	if tokenSource != nil {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(auth.NewTokenCredentials(tokenSource, !c.ClientConfig.Rode.DisableTransportSecurity)))
+	}
+
+	return common.NewRodeClient(c.ClientConfig, dialOptions...)
 }
 
-func (s *staticCredential) RequireTransportSecurity() bool {
-	return s.requireTransportSecurity
+// dialRode connects to Rode over gRPC using the TLS config as the transport credentials
+func dialRode(c *config.Config, tlsConfig *tls.Config, tokenSource oauth2.TokenSource) (action.RodeClient, error) {
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithBlock(),
+		grpc.FailOnNonTempDialError(true),
+	}
+
+	if tokenSource != nil {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(auth.NewTokenCredentials(tokenSource, true)))
+	}
+
+	if basicAuth := c.ClientConfig.BasicAuth; basicAuth.Username != "" || basicAuth.Password != "" {
+		if basicAuth.Username == "" || basicAuth.Password == "" {
+			return nil, errors.New("both username and password must be set for basic auth")
+		}
+
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(auth.NewBasicCredentials(basicAuth.Username, basicAuth.Password)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rodeDialTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, c.ClientConfig.Rode.Host, dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to rode server: %s", err)
+	}
+
+	return v1alpha1.NewRodeClient(conn), nil
+}
+
+// useColor is true when stdout is a terminal, unless colors are turned off with NO_COLOR (https://no-color.org)
+func useColor() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	info, err := os.Stdout.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
 
 func newGitHubClient(c *config.GitHubConfig) *github.Client {
@@ -72,6 +202,15 @@ func newGitHubClient(c *config.GitHubConfig) *github.Client {
 	return github.NewClient(oauth2.NewClient(context.Background(), tokenSource))
 }
 
+func newDigestResolver(c *config.RegistryConfig) (*registry.Resolver, error) {
+	credentials, err := registry.LoadDockerConfig(registry.DockerConfigPath(c.DockerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	return registry.NewResolver(http.DefaultClient, credentials, c.Insecure), nil
+}
+
 func writeEvaluationReport(logger *zap.Logger, directory, report string) string {
 	filePath := path.Join(directory, "report.md")
 	if err := os.WriteFile(filePath, []byte(report), os.ModePerm); err != nil {
@@ -81,6 +220,23 @@ func writeEvaluationReport(logger *zap.Logger, directory, report string) string
 	return filePath
 }
 
+func writeVerificationSummaries(logger *zap.Logger, c *config.Config, summaries string) string {
+	filePath := c.Vsa.Path
+	if !path.IsAbs(filePath) {
+		filePath = path.Join(c.GitHub.Workspace, filePath)
+	}
+
+	if err := os.MkdirAll(path.Dir(filePath), os.ModePerm); err != nil {
+		logger.Fatal("error creating VSA directory", zap.Error(err))
+	}
+
+	if err := os.WriteFile(filePath, []byte(summaries), os.ModePerm); err != nil {
+		logger.Fatal("error writing VSA", zap.Error(err))
+	}
+
+	return filePath
+}
+
 func main() {
 	ctx := context.Background()
 	c, err := config.Build(os.Args[0], os.Args[1:])
@@ -93,34 +249,135 @@ func main() {
 		fatal(fmt.Sprintf("failed to create logger: %s", err))
 	}
 
-	var dialOptions []grpc.DialOption
-	if c.AccessToken != "" {
-		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(&staticCredential{
-			token:                    c.AccessToken,
-			requireTransportSecurity: !c.ClientConfig.Rode.DisableTransportSecurity,
-		}))
+	if c.ConfigFile != "" {
+		logger.Info("Loaded config file", zap.String("path", c.ConfigFile))
 	}
 
-	rodeClient, err := common.NewRodeClient(c.ClientConfig, dialOptions...)
+	rodeClient, err := newRodeClient(c)
 	if err != nil {
 		logger.Fatal("failed to create rode client", zap.Error(err))
 	}
 
-	enforcer := action.NewEnforcerAction(logger, c, rodeClient, newGitHubClient(c.GitHub))
+	switch c.Command {
+	case config.CommandReport:
+		report(ctx, logger, c, rodeClient)
+	case config.CommandListPolicies:
+		listPolicies(ctx, logger, c, rodeClient)
+	case config.CommandTestPolicies:
+		testPolicies(ctx, logger, c, rodeClient)
+	case config.CommandListGroups:
+		listGroups(ctx, logger, c, rodeClient)
+	default:
+		evaluate(ctx, logger, c, rodeClient)
+	}
+}
+
+func evaluate(ctx context.Context, logger *zap.Logger, c *config.Config, rodeClient action.RodeClient) {
+	resolver, err := newDigestResolver(c.Registry)
+	if err != nil {
+		logger.Fatal("failed to create digest resolver", zap.Error(err))
+	}
+
+	// outside of GitHub Actions there are no pull requests to comment on or workflow runs to look up
+	var githubClient *github.Client
+	if c.GitHub.Actions {
+		githubClient = newGitHubClient(c.GitHub)
+	}
+
+	enforcer := action.NewEnforcerAction(logger, c, rodeClient, githubClient, resolver)
 	result, err := enforcer.Run(ctx)
 	if err != nil {
 		logger.Fatal("error evaluating resource", zap.Error(err))
 	}
 
-	logger.Info(result.EvaluationReport)
-	reportPath := writeEvaluationReport(logger, c.GitHub.Workspace, result.EvaluationReport)
+	if result.Skipped {
+		logger.Info("No evaluation was requested")
+		return
+	}
+
+	if err = action.WriteResult(os.Stdout, result, c.Output, c.Output == config.OutputTable && useColor()); err != nil {
+		logger.Fatal("error printing result", zap.Error(err))
+	}
 
-	logger.Info("Wrote evaluation report", zap.String("report", reportPath))
+	if c.GitHub.Actions {
+		reportPath := writeEvaluationReport(logger, c.GitHub.Workspace, result.EvaluationReport)
+
+		logger.Info("Wrote evaluation report", zap.String("report", reportPath))
+
+		setOutputVariable(logger, c.GitHub, "pass", result.Pass)
+		setOutputVariable(logger, c.GitHub, "reportPath", reportPath)
+	}
 
-	setOutputVariable("pass", result.Pass)
-	setOutputVariable("reportPath", reportPath)
+	if result.VerificationSummaries != "" {
+		vsaPath := writeVerificationSummaries(logger, c, result.VerificationSummaries)
+		logger.Info("Wrote verification summaries", zap.String("vsa", vsaPath))
+		if c.GitHub.Actions {
+			setOutputVariable(logger, c.GitHub, "vsaPath", vsaPath)
+		}
+	}
 
 	if result.FailBuild {
 		os.Exit(1)
 	}
 }
+
+func report(ctx context.Context, logger *zap.Logger, c *config.Config, rodeClient action.RodeClient) {
+	result, err := action.NewEnforcerAction(logger, c, rodeClient, nil, nil).Report(ctx, c.EvaluationId)
+	if err != nil {
+		logger.Fatal("error rendering evaluation", zap.Error(err))
+	}
+
+	if err = action.WriteResult(os.Stdout, result, c.Output, c.Output == config.OutputTable && useColor()); err != nil {
+		logger.Fatal("error printing result", zap.Error(err))
+	}
+}
+
+func listPolicies(ctx context.Context, logger *zap.Logger, c *config.Config, rodeClient action.RodeClient) {
+	policies, err := action.NewEnforcerAction(logger, c, rodeClient, nil, nil).AssignedPolicies(ctx, c.PolicyGroup)
+	if err != nil {
+		logger.Fatal("error listing policies", zap.Error(err))
+	}
+
+	if err = action.WritePolicies(os.Stdout, c.PolicyGroup, policies, c.Output); err != nil {
+		logger.Fatal("error printing policies", zap.Error(err))
+	}
+}
+
+func testPolicies(ctx context.Context, logger *zap.Logger, c *config.Config, rodeClient action.RodeClient) {
+	resolver, err := newDigestResolver(c.Registry)
+	if err != nil {
+		logger.Fatal("failed to create digest resolver", zap.Error(err))
+	}
+
+	result, err := action.NewEnforcerAction(logger, c, rodeClient, nil, resolver).TestPolicies(ctx, c.PolicyFiles)
+	if err != nil {
+		logger.Fatal("error testing policies", zap.Error(err))
+	}
+
+	if err = action.WriteResult(os.Stdout, result, c.Output, c.Output == config.OutputTable && useColor()); err != nil {
+		logger.Fatal("error printing result", zap.Error(err))
+	}
+
+	if c.GitHub.Actions {
+		reportPath := writeEvaluationReport(logger, c.GitHub.Workspace, result.EvaluationReport)
+		logger.Info("Wrote policy test report", zap.String("report", reportPath))
+
+		setOutputVariable(logger, c.GitHub, "pass", result.Pass)
+		setOutputVariable(logger, c.GitHub, "reportPath", reportPath)
+	}
+
+	if !result.Pass {
+		os.Exit(1)
+	}
+}
+
+func listGroups(ctx context.Context, logger *zap.Logger, c *config.Config, rodeClient action.RodeClient) {
+	groups, err := action.NewEnforcerAction(logger, c, rodeClient, nil, nil).PolicyGroups(ctx)
+	if err != nil {
+		logger.Fatal("error listing policy groups", zap.Error(err))
+	}
+
+	if err = action.WritePolicyGroups(os.Stdout, groups, c.Output); err != nil {
+		logger.Fatal("error printing policy groups", zap.Error(err))
+	}
+}
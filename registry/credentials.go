@@ -0,0 +1,125 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const dockerHubConfigKey = "https://index.docker.io/v1/"
+
+var osReadFile = os.ReadFile
+
+type Credential struct {
+	Username string
+	Password string
+}
+
+// Credentials maps a registry host to the credential used to authenticate with it
+type Credentials map[string]*Credential
+
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth     string `json:"auth"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"auths"`
+}
+
+// DockerConfigPath returns the location of config.json within a Docker config directory, defaulting to ~/.docker like the Docker CLI
+func DockerConfigPath(dir string) string {
+	if dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// LoadDockerConfig reads the credentials stored in a Docker config file. A missing file isn't an error, as anonymous pulls may be allowed.
+// Credential helpers aren't supported.
+func LoadDockerConfig(path string) (Credentials, error) {
+	credentials := Credentials{}
+	if path == "" {
+		return credentials, nil
+	}
+
+	contents, err := osReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return credentials, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading docker config at %s: %s", path, err)
+	}
+
+	var config dockerConfig
+	if err := json.Unmarshal(contents, &config); err != nil {
+		return nil, fmt.Errorf("error parsing docker config at %s: %s", path, err)
+	}
+
+	for host, auth := range config.Auths {
+		credential := &Credential{Username: auth.Username, Password: auth.Password}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding credentials for %s: %s", host, err)
+			}
+
+			usernamePassword := strings.SplitN(string(decoded), ":", 2)
+			if len(usernamePassword) != 2 {
+				return nil, fmt.Errorf("malformed credentials for %s", host)
+			}
+			credential.Username = usernamePassword[0]
+			credential.Password = usernamePassword[1]
+		}
+
+		credentials[normalizeRegistryHost(host)] = credential
+	}
+
+	return credentials, nil
+}
+
+func (c Credentials) forRegistry(registry string) *Credential {
+	return c[normalizeRegistryHost(registry)]
+}
+
+// normalizeRegistryHost strips the scheme and path that may be present in Docker config keys
+func normalizeRegistryHost(host string) string {
+	if host == dockerHubConfigKey {
+		return dockerHubRegistry
+	}
+
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	host = strings.SplitN(host, "/", 2)[0]
+
+	if host == "index.docker.io" || host == dockerHubApiRegistry {
+		return dockerHubRegistry
+	}
+
+	return host
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Credentials", func() {
+	Context("LoadDockerConfig", func() {
+		var (
+			configPath     string
+			configContents string
+			readFileError  error
+		)
+
+		BeforeEach(func() {
+			configPath = fmt.Sprintf("/%s/config.json", fake.LetterN(10))
+			readFileError = nil
+			configContents = `{
+				"auths": {
+					"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"},
+					"harbor.localhost": {"username": "robot", "password": "secret"}
+				}
+			}`
+
+			osReadFile = func(name string) ([]byte, error) {
+				if name != configPath {
+					return nil, errors.New("wrong file name")
+				}
+
+				return []byte(configContents), readFileError
+			}
+		})
+
+		It("should decode credentials for each registry", func() {
+			credentials, err := LoadDockerConfig(configPath)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(credentials.forRegistry("docker.io")).To(Equal(&Credential{Username: "user", Password: "pass"}))
+			Expect(credentials.forRegistry("harbor.localhost")).To(Equal(&Credential{Username: "robot", Password: "secret"}))
+			Expect(credentials.forRegistry("ghcr.io")).To(BeNil())
+		})
+
+		When("the config file doesn't exist", func() {
+			BeforeEach(func() {
+				readFileError = fs.ErrNotExist
+			})
+
+			It("should return empty credentials", func() {
+				credentials, err := LoadDockerConfig(configPath)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(credentials).To(BeEmpty())
+			})
+		})
+
+		When("the auth value is malformed", func() {
+			BeforeEach(func() {
+				configContents = `{"auths": {"ghcr.io": {"auth": "bm9jb2xvbg=="}}}`
+			})
+
+			It("should return an error", func() {
+				_, err := LoadDockerConfig(configPath)
+
+				Expect(err).To(MatchError(ContainSubstring("ghcr.io")))
+			})
+		})
+	})
+})
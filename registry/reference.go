@@ -0,0 +1,117 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dockerHubRegistry    = "docker.io"
+	dockerHubApiRegistry = "registry-1.docker.io"
+	defaultTag           = "latest"
+)
+
+// Reference is a parsed image reference, e.g. harbor.localhost/rode-demo/app:1.2.3
+type Reference struct {
+	// Name is the image name as it was given, without a tag or digest
+	Name       string
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference splits an image reference into its components, following the same defaulting rules as the Docker CLI:
+// images without a registry are pulled from Docker Hub, and images without a tag or digest use the latest tag.
+func ParseReference(image string) (*Reference, error) {
+	if image == "" || strings.ContainsAny(image, " \t\n") {
+		return nil, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	ref := &Reference{}
+	name := image
+	if i := strings.Index(name, "@"); i != -1 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+
+		if !strings.HasPrefix(ref.Digest, "sha256:") {
+			return nil, fmt.Errorf("invalid digest in image reference %q", image)
+		}
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+
+	if name == "" || (ref.Tag == "" && strings.HasSuffix(image, ":")) {
+		return nil, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+
+	ref.Name = name
+	ref.Registry = dockerHubRegistry
+	ref.Repository = name
+
+	components := strings.SplitN(name, "/", 2)
+	if len(components) == 2 && (strings.ContainsAny(components[0], ".:") || components[0] == "localhost") {
+		ref.Registry = components[0]
+		ref.Repository = components[1]
+	}
+
+	if ref.Registry == dockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	return ref, nil
+}
+
+// IsDigest is true when the reference is already pinned to a digest
+func (r *Reference) IsDigest() bool {
+	return r.Digest != ""
+}
+
+// DigestUri is the fully qualified resource URI that Rode expects for a Docker image. The registry and repository are
+// always included, so that Docker Hub short names like nginx become docker.io/library/nginx.
+func (r *Reference) DigestUri(digest string) string {
+	return fmt.Sprintf("%s/%s@%s", r.Registry, r.Repository, digest)
+}
+
+// String is the fully qualified reference, so that references to the same image compare equal however they were written
+func (r *Reference) String() string {
+	reference := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		reference += ":" + r.Tag
+	}
+
+	if r.Digest != "" {
+		reference += "@" + r.Digest
+	}
+
+	return reference
+}
+
+func (r *Reference) apiHost() string {
+	if r.Registry == dockerHubRegistry {
+		return dockerHubApiRegistry
+	}
+
+	return r.Registry
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reference", func() {
+	Context("ParseReference", func() {
+		DescribeTable("parsing image references", func(image string, expected *Reference) {
+			actual, err := ParseReference(image)
+
+			if expected == nil {
+				Expect(err).To(HaveOccurred())
+			} else {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).To(Equal(expected))
+			}
+		},
+			Entry("official Docker Hub image", "nginx:1.21", &Reference{
+				Name:       "nginx",
+				Registry:   "docker.io",
+				Repository: "library/nginx",
+				Tag:        "1.21",
+			}),
+			Entry("Docker Hub image without a tag", "rode/enforcer-action", &Reference{
+				Name:       "rode/enforcer-action",
+				Registry:   "docker.io",
+				Repository: "rode/enforcer-action",
+				Tag:        "latest",
+			}),
+			Entry("private registry with a port", "localhost:5000/team/app:v1", &Reference{
+				Name:       "localhost:5000/team/app",
+				Registry:   "localhost:5000",
+				Repository: "team/app",
+				Tag:        "v1",
+			}),
+			Entry("digest reference", "harbor.localhost/rode-demo/app@sha256:abc123", &Reference{
+				Name:       "harbor.localhost/rode-demo/app",
+				Registry:   "harbor.localhost",
+				Repository: "rode-demo/app",
+				Digest:     "sha256:abc123",
+			}),
+			Entry("tag and digest", "ghcr.io/rode/app:v1@sha256:abc123", &Reference{
+				Name:       "ghcr.io/rode/app",
+				Registry:   "ghcr.io",
+				Repository: "rode/app",
+				Tag:        "v1",
+				Digest:     "sha256:abc123",
+			}),
+			Entry("empty reference", "", nil),
+			Entry("empty tag", "nginx:", nil),
+			Entry("invalid digest", "nginx@md5:abc", nil),
+			Entry("whitespace", "nginx latest", nil),
+		)
+	})
+
+	Context("DigestUri", func() {
+		DescribeTable("building resource uris", func(image, expected string) {
+			ref, err := ParseReference(image)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ref.DigestUri("sha256:abc123")).To(Equal(expected))
+		},
+			Entry("official Docker Hub image", "nginx:1.21", "docker.io/library/nginx@sha256:abc123"),
+			Entry("Docker Hub short name", "rode/enforcer-action", "docker.io/rode/enforcer-action@sha256:abc123"),
+			Entry("explicit Docker Hub registry", "docker.io/library/nginx", "docker.io/library/nginx@sha256:abc123"),
+			Entry("private registry", "harbor.localhost/rode-demo/app:v1", "harbor.localhost/rode-demo/app@sha256:abc123"),
+			Entry("private registry with a port", "localhost:5000/team/app", "localhost:5000/team/app@sha256:abc123"),
+		)
+	})
+
+	Context("String", func() {
+		DescribeTable("normalizing image references", func(image, expected string) {
+			ref, err := ParseReference(image)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ref.String()).To(Equal(expected))
+		},
+			Entry("Docker Hub short name", "nginx", "docker.io/library/nginx:latest"),
+			Entry("fully qualified Docker Hub image", "docker.io/library/nginx:latest", "docker.io/library/nginx:latest"),
+			Entry("private registry with a tag and digest", "harbor.localhost/rode-demo/app:v1@sha256:abc123", "harbor.localhost/rode-demo/app:v1@sha256:abc123"),
+			Entry("digest without a tag", "redis@sha256:abc123", "docker.io/library/redis@sha256:abc123"),
+		)
+	})
+})
@@ -0,0 +1,273 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const digestHeader = "Docker-Content-Digest"
+
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Resolver looks up image digests using the OCI Distribution API
+type Resolver struct {
+	client      *http.Client
+	credentials Credentials
+	insecure    bool
+}
+
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+// NewResolver creates a Resolver. When insecure is set, registries are contacted over plain HTTP, which is useful for a local registry.
+func NewResolver(client *http.Client, credentials Credentials, insecure bool) *Resolver {
+	return &Resolver{
+		client:      client,
+		credentials: credentials,
+		insecure:    insecure,
+	}
+}
+
+// ResolveDigest returns the fully qualified digest URI for an image. Images that are already pinned to a digest are
+// qualified without contacting the registry.
+func (r *Resolver) ResolveDigest(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.IsDigest() {
+		return ref.DigestUri(ref.Digest), nil
+	}
+
+	digest, err := r.fetchDigest(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("error resolving digest for %s: %s", image, err)
+	}
+
+	return ref.DigestUri(digest), nil
+}
+
+func (r *Resolver) fetchDigest(ctx context.Context, ref *Reference) (string, error) {
+	scheme := "https"
+	if r.insecure {
+		scheme = "http"
+	}
+	manifestUrl := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, ref.apiHost(), ref.Repository, ref.Tag)
+
+	authorization := ""
+	response, err := r.requestManifest(ctx, http.MethodHead, manifestUrl, authorization)
+	if err != nil {
+		return "", err
+	}
+	response.Body.Close()
+
+	if response.StatusCode == http.StatusUnauthorized {
+		authorization, err = r.authorize(ctx, ref, response.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+
+		response, err = r.requestManifest(ctx, http.MethodHead, manifestUrl, authorization)
+		if err != nil {
+			return "", err
+		}
+		response.Body.Close()
+	}
+
+	if digest := response.Header.Get(digestHeader); response.StatusCode == http.StatusOK && digest != "" {
+		return digest, nil
+	}
+
+	// some registries don't return the digest header on HEAD requests, so fetch the manifest and hash it instead
+	return r.fetchManifestDigest(ctx, manifestUrl, authorization)
+}
+
+func (r *Resolver) fetchManifestDigest(ctx context.Context, manifestUrl, authorization string) (string, error) {
+	response, err := r.requestManifest(ctx, http.MethodGet, manifestUrl, authorization)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d fetching manifest", response.StatusCode)
+	}
+
+	if digest := response.Header.Get(digestHeader); digest != "" {
+		return digest, nil
+	}
+
+	manifest, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading manifest: %s", err)
+	}
+
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)), nil
+}
+
+func (r *Resolver) requestManifest(ctx context.Context, method, manifestUrl, authorization string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, manifestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting manifest: %s", err)
+	}
+
+	return response, nil
+}
+
+// authorize responds to the registry's authentication challenge, either with basic auth or by requesting a bearer token
+// see https://docs.docker.com/registry/spec/auth/token/
+func (r *Resolver) authorize(ctx context.Context, ref *Reference, challenge string) (string, error) {
+	credential := r.credentials.forRegistry(ref.Registry)
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if credential == nil {
+			return "", fmt.Errorf("registry %s requires credentials", ref.Registry)
+		}
+
+		request := &http.Request{Header: http.Header{}}
+		request.SetBasicAuth(credential.Username, credential.Password)
+
+		return request.Header.Get("Authorization"), nil
+	case "bearer":
+		return r.requestToken(ctx, ref, params, credential)
+	}
+
+	return "", fmt.Errorf("unsupported authentication challenge from registry %s: %q", ref.Registry, challenge)
+}
+
+func (r *Resolver) requestToken(ctx context.Context, ref *Reference, params map[string]string, credential *Credential) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+
+	query := realm.Query()
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if credential != nil {
+		request.SetBasicAuth(credential.Username, credential.Password)
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("error requesting registry token: %s", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d requesting registry token", response.StatusCode)
+	}
+
+	var token tokenResponse
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("error decoding registry token: %s", err)
+	}
+
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+
+	if token.Token == "" {
+		return "", fmt.Errorf("registry token response did not include a token")
+	}
+
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallenge parses a WWW-Authenticate header of the form: Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+
+	for _, param := range splitChallengeParams(parts[1]) {
+		keyValue := strings.SplitN(param, "=", 2)
+		if len(keyValue) != 2 {
+			continue
+		}
+
+		params[strings.ToLower(strings.TrimSpace(keyValue[0]))] = strings.Trim(strings.TrimSpace(keyValue[1]), `"`)
+	}
+
+	return parts[0], params
+}
+
+// splitChallengeParams splits on commas that aren't inside quotes, since scopes may contain commas (e.g., repository:foo:pull,push)
+func splitChallengeParams(params string) []string {
+	var (
+		result   []string
+		current  strings.Builder
+		inQuotes bool
+	)
+
+	for _, char := range params {
+		switch {
+		case char == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(char)
+		case char == ',' && !inQuotes:
+			result = append(result, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(char)
+		}
+	}
+
+	return append(result, current.String())
+}
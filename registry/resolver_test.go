@@ -0,0 +1,207 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resolver", func() {
+	var (
+		ctx      = context.Background()
+		server   *httptest.Server
+		resolver *Resolver
+
+		credentials     Credentials
+		challenge       string
+		expectedDigest  string
+		expectedToken   string
+		manifest        string
+		omitHeadDigest  bool
+		tokenRequests   int
+		requireUsername string
+	)
+
+	BeforeEach(func() {
+		credentials = Credentials{}
+		expectedToken = fake.LetterN(20)
+		manifest = fmt.Sprintf(`{"schemaVersion": 2, "config": {"digest": "%s"}}`, fake.LetterN(10))
+		expectedDigest = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(manifest)))
+		omitHeadDigest = false
+		tokenRequests = 0
+		requireUsername = ""
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			tokenRequests++
+			Expect(r.URL.Query().Get("scope")).To(Equal("repository:team/app:pull"))
+			Expect(r.URL.Query().Get("service")).To(Equal("test-registry"))
+
+			if requireUsername != "" {
+				username, _, ok := r.BasicAuth()
+				if !ok || username != requireUsername {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+			}
+
+			fmt.Fprintf(w, `{"token": "%s"}`, expectedToken)
+		})
+		mux.HandleFunc("/v2/team/app/manifests/", func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasSuffix(r.URL.Path, "/v1") {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			if challenge != "" && !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "+expectedToken) && !strings.HasPrefix(r.Header.Get("Authorization"), "Basic ") {
+				w.Header().Set("WWW-Authenticate", challenge)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			Expect(r.Header.Get("Accept")).To(ContainSubstring("application/vnd.oci.image.index.v1+json"))
+			if r.Method == http.MethodHead && omitHeadDigest {
+				return
+			}
+
+			if r.Method == http.MethodHead {
+				w.Header().Set(digestHeader, expectedDigest)
+				return
+			}
+
+			fmt.Fprint(w, manifest)
+		})
+
+		server = httptest.NewServer(mux)
+		challenge = fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="repository:team/app:pull"`, server.URL)
+	})
+
+	JustBeforeEach(func() {
+		resolver = NewResolver(server.Client(), credentials, true)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	image := func(tag string) string {
+		return fmt.Sprintf("%s/team/app:%s", strings.TrimPrefix(server.URL, "http://"), tag)
+	}
+
+	It("should resolve the tag to a digest using an anonymous token", func() {
+		actual, err := resolver.ResolveDigest(ctx, image("v1"))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual).To(Equal(fmt.Sprintf("%s/team/app@%s", strings.TrimPrefix(server.URL, "http://"), expectedDigest)))
+		Expect(tokenRequests).To(Equal(1))
+	})
+
+	When("the registry requires credentials for a token", func() {
+		BeforeEach(func() {
+			requireUsername = fake.Username()
+		})
+
+		It("should use the configured credentials", func() {
+			credentials[strings.TrimPrefix(server.URL, "http://")] = &Credential{Username: requireUsername, Password: fake.LetterN(10)}
+
+			_, err := resolver.ResolveDigest(ctx, image("v1"))
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should return an error when credentials are missing", func() {
+			_, err := resolver.ResolveDigest(ctx, image("v1"))
+
+			Expect(err).To(MatchError(ContainSubstring("requesting registry token")))
+		})
+	})
+
+	When("the registry uses basic auth", func() {
+		BeforeEach(func() {
+			challenge = `Basic realm="registry"`
+			credentials[strings.TrimPrefix(server.URL, "http://")] = &Credential{Username: fake.Username(), Password: fake.LetterN(10)}
+		})
+
+		It("should resolve the digest", func() {
+			actual, err := resolver.ResolveDigest(ctx, image("v1"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(HaveSuffix(expectedDigest))
+			Expect(tokenRequests).To(Equal(0))
+		})
+	})
+
+	When("the registry doesn't require authentication", func() {
+		BeforeEach(func() {
+			challenge = ""
+		})
+
+		It("should resolve the digest", func() {
+			actual, err := resolver.ResolveDigest(ctx, image("v1"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(HaveSuffix(expectedDigest))
+		})
+	})
+
+	When("the registry doesn't return a digest for HEAD requests", func() {
+		BeforeEach(func() {
+			omitHeadDigest = true
+		})
+
+		It("should hash the manifest", func() {
+			actual, err := resolver.ResolveDigest(ctx, image("v1"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(HaveSuffix(expectedDigest))
+		})
+	})
+
+	When("the tag doesn't exist", func() {
+		It("should return an error", func() {
+			_, err := resolver.ResolveDigest(ctx, image("v2"))
+
+			Expect(err).To(MatchError(ContainSubstring("status code 404")))
+		})
+	})
+
+	When("the image is already pinned to a digest", func() {
+		It("should return the image without contacting the registry", func() {
+			pinned := "harbor.localhost/team/app@" + expectedDigest
+
+			actual, err := resolver.ResolveDigest(ctx, pinned)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal(pinned))
+			Expect(tokenRequests).To(Equal(0))
+		})
+
+		It("should fully qualify short Docker Hub names and drop the tag", func() {
+			actual, err := resolver.ResolveDigest(ctx, "nginx:1.21@"+expectedDigest)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal("docker.io/library/nginx@" + expectedDigest))
+			Expect(tokenRequests).To(Equal(0))
+		})
+	})
+})
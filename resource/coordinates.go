@@ -0,0 +1,97 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// NpmUri builds a resource URI from an npm package in the form name@version, e.g. @rode/demo@1.0.0
+func NpmUri(pkg string) (string, error) {
+	name, version, err := splitNameVersion(pkg, "@")
+	if err != nil {
+		return "", fmt.Errorf("invalid npm package %q: %s", pkg, err)
+	}
+
+	return validated(fmt.Sprintf("npm://%s:%s", name, version))
+}
+
+// PipUri builds a resource URI from a PyPI package in the form name@version or name==version
+func PipUri(pkg string) (string, error) {
+	separator := "@"
+	if strings.Contains(pkg, "==") {
+		separator = "=="
+	}
+
+	name, version, err := splitNameVersion(pkg, separator)
+	if err != nil {
+		return "", fmt.Errorf("invalid pip package %q: %s", pkg, err)
+	}
+
+	return validated(fmt.Sprintf("pip://%s:%s", name, version))
+}
+
+// NugetUri builds a resource URI from a NuGet package in the form name@version
+func NugetUri(pkg string) (string, error) {
+	name, version, err := splitNameVersion(pkg, "@")
+	if err != nil {
+		return "", fmt.Errorf("invalid nuget package %q: %s", pkg, err)
+	}
+
+	return validated(fmt.Sprintf("nuget://%s:%s", name, version))
+}
+
+// MavenUri builds a resource URI from Maven coordinates in the form group:artifact:version
+func MavenUri(coordinates string) (string, error) {
+	if len(strings.Split(coordinates, ":")) != 3 {
+		return "", fmt.Errorf("invalid maven coordinates %q: expected group:artifact:version", coordinates)
+	}
+
+	return validated("gav://" + coordinates)
+}
+
+// GitUri builds a resource URI for a commit in a repository hosted on a git server, e.g. https://github.com and org/repo
+func GitUri(serverUrl, repository, commit string) (string, error) {
+	server, err := url.Parse(serverUrl)
+	if err != nil || server.Host == "" {
+		return "", fmt.Errorf("invalid git server url %q", serverUrl)
+	}
+
+	if repository == "" {
+		return "", fmt.Errorf("a repository is required to build a git resource uri")
+	}
+
+	return validated(fmt.Sprintf("git://%s/%s@%s", server.Host, strings.Trim(repository, "/"), strings.ToLower(commit)))
+}
+
+// splitNameVersion splits on the last separator, since scoped npm packages begin with @
+func splitNameVersion(pkg, separator string) (string, string, error) {
+	i := strings.LastIndex(pkg, separator)
+	if i <= 0 || i == len(pkg)-len(separator) {
+		return "", "", fmt.Errorf("expected name%sversion", separator)
+	}
+
+	return pkg[:i], pkg[i+len(separator):], nil
+}
+
+func validated(uri string) (string, error) {
+	if _, err := Parse(uri); err != nil {
+		return "", err
+	}
+
+	return uri, nil
+}
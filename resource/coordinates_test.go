@@ -0,0 +1,80 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Package coordinates", func() {
+	commit := fake.Regex("[a-f0-9]{40}")
+
+	DescribeTable("building resource uris", func(build func(string) (string, error), coordinates, expected string) {
+		actual, err := build(coordinates)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual).To(Equal(expected))
+	},
+		Entry("npm", NpmUri, "express@4.17.1", "npm://express:4.17.1"),
+		Entry("scoped npm", NpmUri, "@rode/demo@1.0.0", "npm://@rode/demo:1.0.0"),
+		Entry("pip", PipUri, "requests@2.25.1", "pip://requests:2.25.1"),
+		Entry("pip requirement", PipUri, "requests==2.25.1", "pip://requests:2.25.1"),
+		Entry("nuget", NugetUri, "log4net@9.0.1", "nuget://log4net:9.0.1"),
+		Entry("maven", MavenUri, "org.apache:commons:1.0", "gav://org.apache:commons:1.0"),
+	)
+
+	DescribeTable("invalid coordinates", func(build func(string) (string, error), coordinates string) {
+		actual, err := build(coordinates)
+
+		Expect(err).To(HaveOccurred())
+		Expect(actual).To(BeEmpty())
+	},
+		Entry("npm without a version", NpmUri, "express"),
+		Entry("scoped npm without a version", NpmUri, "@rode/demo"),
+		Entry("npm with a trailing @", NpmUri, "express@"),
+		Entry("pip without a version", PipUri, "requests"),
+		Entry("nuget without a name", NugetUri, "@9.0.1"),
+		Entry("maven without a group", MavenUri, "commons:1.0"),
+	)
+
+	Describe("GitUri", func() {
+		It("should build a uri from the server url and repository", func() {
+			actual, err := GitUri("https://github.com", "rode/enforcer-action", commit)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal("git://github.com/rode/enforcer-action@" + commit))
+		})
+
+		It("should return an error for an invalid commit", func() {
+			_, err := GitUri("https://github.com", "rode/enforcer-action", "main")
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error without a repository", func() {
+			_, err := GitUri("https://github.com", "", commit)
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error for an invalid server url", func() {
+			_, err := GitUri(fake.LetterN(10), "rode/enforcer-action", commit)
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,140 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	rode "github.com/rode/rode/proto/v1alpha1"
+)
+
+// ErrUnpinnedImage is returned for image references that use a tag instead of a digest, which Rode can't match to occurrences
+var ErrUnpinnedImage = errors.New("image is not pinned by digest")
+
+type uriFormat struct {
+	prefix  string
+	pattern *regexp.Regexp
+	example string
+}
+
+// these follow the resource URI formats that Rode understands, but are stricter about versions so that typos are caught
+var uriFormats = map[rode.ResourceType]*uriFormat{
+	rode.ResourceType_DOCKER: {
+		prefix:  "",
+		pattern: regexp.MustCompile("^(?P<name>[^@\\s]+)@(?P<version>sha256:[a-f0-9]{64})$"),
+		example: "harbor.localhost/team/app@sha256:<64 hex characters>",
+	},
+	rode.ResourceType_GIT: {
+		prefix:  "git://",
+		pattern: regexp.MustCompile("^git://(?P<name>[^@\\s]+/[^@\\s]+)@(?P<version>[a-f0-9]{7,64})$"),
+		example: "git://github.com/org/repo@<commit sha>",
+	},
+	rode.ResourceType_MAVEN: {
+		prefix:  "gav://",
+		pattern: regexp.MustCompile("^gav://(?P<name>[^:\\s]+:[^:\\s]+):(?P<version>[^:\\s]+)$"),
+		example: "gav://group:artifact:version",
+	},
+	rode.ResourceType_FILE: {
+		prefix:  "file://",
+		pattern: regexp.MustCompile("^file://sha256:(?P<version>[a-f0-9]{64}):(?P<name>\\S+)$"),
+		example: "file://sha256:<64 hex characters>:name",
+	},
+	rode.ResourceType_NPM: {
+		prefix:  "npm://",
+		pattern: regexp.MustCompile("^npm://(?P<name>(@[^/:\\s]+/)?[^@/:\\s]+):(?P<version>[^:\\s]+)$"),
+		example: "npm://name:version",
+	},
+	rode.ResourceType_NUGET: {
+		prefix:  "nuget://",
+		pattern: regexp.MustCompile("^nuget://(?P<name>[^:\\s]+):(?P<version>[^:\\s]+)$"),
+		example: "nuget://name:version",
+	},
+	rode.ResourceType_PIP: {
+		prefix:  "pip://",
+		pattern: regexp.MustCompile("^pip://(?P<name>[^:\\s]+):(?P<version>[^:\\s]+)$"),
+		example: "pip://name:version",
+	},
+	rode.ResourceType_DEBIAN: {
+		prefix:  "deb://",
+		pattern: regexp.MustCompile("^deb://([^:\\s]+:)?[^:\\s]+:(?P<name>[^:\\s]+):(?P<version>[^:\\s]+)$"),
+		example: "deb://[distribution:]architecture:name:version",
+	},
+	rode.ResourceType_RPM: {
+		prefix:  "rpm://",
+		pattern: regexp.MustCompile("^rpm://([^:\\s]+:)?[^:\\s]+:(?P<name>[^:\\s]+):(?P<version>[^:\\s]+)$"),
+		example: "rpm://[distribution:]architecture:name:version",
+	},
+}
+
+// Uri is a validated resource URI
+type Uri struct {
+	Type    rode.ResourceType
+	Name    string
+	Version string
+	Uri     string
+}
+
+// Parse determines the type of a resource URI from its prefix and validates it against the format for that type
+func Parse(uri string) (*Uri, error) {
+	resourceType, format, err := formatForUri(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := format.pattern.FindStringSubmatch(uri)
+	if matches == nil {
+		if resourceType == rode.ResourceType_DOCKER && !strings.ContainsAny(uri, " \t\n") {
+			return nil, fmt.Errorf("%w: %q (expected %s)", ErrUnpinnedImage, uri, format.example)
+		}
+
+		return nil, fmt.Errorf("malformed %s resource uri %q (expected %s)", strings.ToLower(resourceType.String()), uri, format.example)
+	}
+
+	return &Uri{
+		Type:    resourceType,
+		Name:    matches[format.pattern.SubexpIndex("name")],
+		Version: matches[format.pattern.SubexpIndex("version")],
+		Uri:     uri,
+	}, nil
+}
+
+func formatForUri(uri string) (rode.ResourceType, *uriFormat, error) {
+	if uri == "" {
+		return 0, nil, errors.New("resource uri is empty")
+	}
+
+	if !strings.Contains(uri, "://") {
+		return rode.ResourceType_DOCKER, uriFormats[rode.ResourceType_DOCKER], nil
+	}
+
+	var prefixes []string
+	for resourceType, format := range uriFormats {
+		if format.prefix == "" {
+			continue
+		}
+
+		if strings.HasPrefix(uri, format.prefix) {
+			return resourceType, format, nil
+		}
+		prefixes = append(prefixes, format.prefix)
+	}
+	sort.Strings(prefixes)
+
+	return 0, nil, fmt.Errorf("unsupported resource uri %q (expected an image digest or one of the prefixes %s)", uri, strings.Join(prefixes, ", "))
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 The Rode Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	rode "github.com/rode/rode/proto/v1alpha1"
+)
+
+var _ = Describe("Resource URIs", func() {
+	digest := fake.Regex("[a-f0-9]{64}")
+	commit := fake.Regex("[a-f0-9]{40}")
+
+	DescribeTable("valid resource uris", func(uri string, expected *Uri) {
+		actual, err := Parse(uri)
+
+		Expect(err).NotTo(HaveOccurred())
+		expected.Uri = uri
+		Expect(actual).To(Equal(expected))
+	},
+		Entry("image", "harbor.localhost/rode/app@sha256:"+digest, &Uri{Type: rode.ResourceType_DOCKER, Name: "harbor.localhost/rode/app", Version: "sha256:" + digest}),
+		Entry("git", "git://github.com/rode/enforcer-action@"+commit, &Uri{Type: rode.ResourceType_GIT, Name: "github.com/rode/enforcer-action", Version: commit}),
+		Entry("maven", "gav://ant:ant:1.6.5", &Uri{Type: rode.ResourceType_MAVEN, Name: "ant:ant", Version: "1.6.5"}),
+		Entry("file", "file://sha256:"+digest+":app.tar.gz", &Uri{Type: rode.ResourceType_FILE, Name: "app.tar.gz", Version: digest}),
+		Entry("npm", "npm://express:4.17.1", &Uri{Type: rode.ResourceType_NPM, Name: "express", Version: "4.17.1"}),
+		Entry("scoped npm", "npm://@rode/demo:1.0.0", &Uri{Type: rode.ResourceType_NPM, Name: "@rode/demo", Version: "1.0.0"}),
+		Entry("nuget", "nuget://log4net:9.0.1", &Uri{Type: rode.ResourceType_NUGET, Name: "log4net", Version: "9.0.1"}),
+		Entry("pip", "pip://raven:5.13.0", &Uri{Type: rode.ResourceType_PIP, Name: "raven", Version: "5.13.0"}),
+		Entry("debian", "deb://lucid:i386:acl:2.2.49-2", &Uri{Type: rode.ResourceType_DEBIAN, Name: "acl", Version: "2.2.49-2"}),
+		Entry("debian without a distribution", "deb://i386:acl:2.2.49-2", &Uri{Type: rode.ResourceType_DEBIAN, Name: "acl", Version: "2.2.49-2"}),
+		Entry("rpm", "rpm://el6:i386:ImageMagick:6.7.2.7-4", &Uri{Type: rode.ResourceType_RPM, Name: "ImageMagick", Version: "6.7.2.7-4"}),
+	)
+
+	DescribeTable("invalid resource uris", func(uri string, expectedError string) {
+		actual, err := Parse(uri)
+
+		Expect(actual).To(BeNil())
+		Expect(err).To(MatchError(ContainSubstring(expectedError)))
+	},
+		Entry("empty", "", "empty"),
+		Entry("unknown prefix", "go://github.com/rode/rode@v0.14.9", "unsupported resource uri"),
+		Entry("url", "https://github.com/rode/rode", "unsupported resource uri"),
+		Entry("short digest", "harbor.localhost/rode/app@sha256:"+digest[:10], "not pinned by digest"),
+		Entry("git without a commit", "git://github.com/rode/enforcer-action", "malformed git resource uri"),
+		Entry("git with a branch", "git://github.com/rode/enforcer-action@main", "malformed git resource uri"),
+		Entry("maven without a version", "gav://ant:ant", "malformed maven resource uri"),
+		Entry("file without a digest", "file://app.tar.gz", "malformed file resource uri"),
+		Entry("npm with @", "npm://express@4.17.1", "malformed npm resource uri"),
+		Entry("pip without a version", "pip://raven", "malformed pip resource uri"),
+		Entry("debian without an architecture", "deb://acl:2.2.49-2", "malformed debian resource uri"),
+	)
+
+	It("should identify images that use a tag instead of a digest", func() {
+		_, err := Parse("harbor.localhost/rode/app:1.2.3")
+
+		Expect(err).To(MatchError(ErrUnpinnedImage))
+	})
+})